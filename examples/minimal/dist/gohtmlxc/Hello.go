@@ -14,9 +14,11 @@ func HelloComp(props Hello, attrs Attrs, children ...Element) Element {
 	if props.Attrs == nil {
 		props.Attrs = Attrs{}
 	}
-	return R(E(`div`, Attrs{`class`: `greeting`}, R(`
-  `), E(`p`, Attrs{}, R(R(`Hello, `, props.Name, `!`))), R(`
+	return ComponentAt("Hello", "hello.html", 1, func(ctx Context) Element {
+		return R(E(`div`, AttrList{{Key: `class`, Value: `greeting`}}, Literal(`
+  `), E(`p`, nil, R(Literal(`Hello, `), props.Name, Literal(`!`))), Literal(`
 `)))
+	})
 }
 
 func (c Hello) Get(children ...Element) Element {
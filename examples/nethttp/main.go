@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	gc "github.com/abdheshnayak/gohtmlx/examples/nethttp/dist/gohtmlxc"
+	"github.com/abdheshnayak/gohtmlx/pkg/element"
 )
 
 func main() {
@@ -15,7 +16,8 @@ func main() {
 		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		el := gc.Hello{Name: name, Attrs: nil}.Get()
-		if _, err := el.Render(w); err != nil {
+		// The request context stops rendering if the client disconnects.
+		if _, err := element.RenderContext(r.Context(), el, w); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
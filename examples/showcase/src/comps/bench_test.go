@@ -0,0 +1,25 @@
+package comps
+
+import (
+	"io"
+	"testing"
+)
+
+// Run after transpiling the showcase: go run . --src=examples/showcase/src --dist=examples/showcase/dist
+func BenchmarkHome(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Home().Render(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkServerTime(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ServerTime("2025-01-01 12:00:00 UTC", "").Render(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
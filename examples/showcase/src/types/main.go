@@ -17,7 +17,7 @@ type Feature struct {
 	Title       string
 	Description string
 	Icon        string
-	Code        string // optional; HTML-escaped snippet shown below description when ShowCode is true
+	Code        string // optional; snippet shown below description when ShowCode is true (escaped at render)
 	ShowCode    bool
 	Language    string // optional; for syntax highlighting, e.g. "language-go", "language-bash", "language-html"
 }
@@ -41,7 +41,7 @@ type DocSection struct {
 	ShowBadge bool
 }
 
-// CodeExample holds a teaching snippet (title, optional description, code shown as-is; code should be HTML-escaped).
+// CodeExample holds a teaching snippet (title, optional description, code; escaped at render).
 type CodeExample struct {
 	Title           string
 	Description     string
@@ -46,15 +46,16 @@ func main() {
 	pkg := flag.String("pkg", "gohtmlxc", "generated package name")
 	validateTypes := flag.Bool("validate-types", false, "after codegen, run go build on the generated package and fail with file/line on error (run from module root)")
 	incremental := flag.Bool("incremental", false, "skip transpilation if no .html file is newer than generated .go files (for watch scripts)")
+	backend := flag.String("backend", transpiler.BackendTree, "generated code: tree (builds elements) or writer (writes output directly; fastest)")
 	flag.Parse()
 
-	if *src == "" || *dist == "" {
+	if *src == "" || *dist == "" || *backend != transpiler.BackendTree && *backend != transpiler.BackendWriter {
 		flag.Usage()
 		os.Exit(2)
 	}
 
 	utils.Log = utils.NewSlogLogger(slog.Default())
-	opts := &transpiler.RunOptions{SingleFile: *singleFile, Pkg: *pkg, ValidateTypes: *validateTypes, Incremental: *incremental}
+	opts := &transpiler.RunOptions{SingleFile: *singleFile, Pkg: *pkg, ValidateTypes: *validateTypes, Incremental: *incremental, Backend: *backend}
 	if err := transpiler.Run(*src, *dist, opts); err != nil {
 		utils.Log.Error("transpiling failed", "err", err)
 		os.Exit(1)
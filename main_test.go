@@ -253,3 +253,34 @@ func repoRoot() string {
 		dir = parent
 	}
 }
+
+func TestRun_IntegrationBuildWriter(t *testing.T) {
+	root := repoRoot()
+	if root == "" {
+		t.Skip("repo root (go.mod) not found")
+	}
+	// "ctx" in template text, and ctx used only inside an await, where it is the await's own.
+	src := t.TempDir()
+	content := "<!-- + define \"Note\" -->\n<!-- | define \"props\" -->\ncount: int\n<!-- | end -->\n" +
+		"<!-- | define \"html\" -->\n<div><p>The ctx value is {props.Count}</p>" +
+		"<await><p>{ctx.Value(\"k\")}</p></await></div>\n<!-- | end -->\n<!-- + end -->\n"
+	if err := os.WriteFile(filepath.Join(src, "note.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dist := filepath.Join(root, "testdata", "writer-out")
+	_ = os.RemoveAll(dist)
+	defer os.RemoveAll(dist)
+	utils.Log = utils.NewSlogLogger(slog.Default())
+	if err := transpiler.Run(src, dist, &transpiler.RunOptions{Backend: transpiler.BackendWriter}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	relPkg, _ := filepath.Rel(root, filepath.Join(dist, "gohtmlxc"))
+	pkgPath := "./" + filepath.ToSlash(relPkg)
+	cmd := exec.Command("go", "build", pkgPath)
+	cmd.Dir = root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("go build %s: %v", pkgPath, err)
+	}
+}
@@ -0,0 +1,174 @@
+package element
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"strconv"
+)
+
+// StreamMode selects how RenderWithOptions streams Await content.
+type StreamMode int
+
+const (
+	// StreamScript writes each resolved Await as a <template> followed by an inline script
+	// that moves it into the placeholder. Use it for full page loads; pages with a strict
+	// Content-Security-Policy must allow the inline scripts.
+	StreamScript StreamMode = iota + 1
+	// StreamOOB writes each resolved Await as an HTMX out-of-band swap
+	// (hx-swap-oob="outerHTML") that replaces the placeholder. HTMX applies out-of-band swaps
+	// before the main content of the same response, so the placeholder must already be in
+	// the page when a chunk is swapped: use it with a client that swaps each chunk as it
+	// arrives. For a regular HTMX request use StreamScript, whose scripts HTMX runs after the
+	// swap.
+	StreamOOB
+)
+
+// awaitTag is the placeholder element; display:contents keeps it out of the layout.
+const awaitTag = "gohtmlx-await"
+
+// awaitScript defines the function StreamScript chunks call to swap in their content.
+const awaitScript = `<script>function gohtmlxAwait(i){var t=document.currentScript.previousElementSibling,` +
+	`p=document.getElementById(i);if(p){p.replaceWith(t.content)}t.remove()}</script>`
+
+// Await renders the content built by fn, which may be slow (e.g. a widget that queries a
+// database). Under RenderWithOptions with Stream set, the rest of the page does not wait for
+// it: fallback is rendered in a placeholder, the page is flushed, fn runs concurrently with the
+// other Awaits, and each result is streamed after the page as it resolves, in whatever order
+// they finish. Otherwise fn's content renders in place and fallback is unused.
+//
+// The transpiler emits Await for <await> with an optional <fallback> child. fn runs on its
+// own goroutine with the render context, so it must not write state shared with the rest of
+// the page. Awaits inside fn's content render in place, with their enclosing Await.
+func Await(fallback Element, fn func(ctx Context) Element) Element {
+	return awaitElement{fallback: fallback, fn: fn}
+}
+
+type awaitElement struct {
+	fallback Element
+	fn       func(ctx Context) Element
+}
+
+func (a awaitElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, a)
+}
+
+func (a awaitElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, a)
+}
+
+func (a awaitElement) renderTo(rw *renderWriter) {
+	if rw.stream == nil || rw.searching() {
+		renderChild(rw, a.fn(rw.ctx))
+		return
+	}
+	rw.stream.start(rw, a)
+}
+
+// stream is the state of a streaming render: the Awaits started and their results.
+type stream struct {
+	opts *RenderOptions
+	// prefix starts the placeholder ids, so the placeholders of separate renders swapped into
+	// one page do not share ids.
+	prefix  string
+	started int
+	results chan awaitResult
+	// abandon is closed when the render stops waiting, releasing pending senders.
+	abandon chan struct{}
+}
+
+type awaitResult struct {
+	id   int
+	body []byte
+	err  error
+}
+
+// start renders a's placeholder into rw and resolves a's content on a new goroutine.
+func (s *stream) start(rw *renderWriter, a awaitElement) {
+	if s.results == nil {
+		s.results, s.abandon = make(chan awaitResult), make(chan struct{})
+		s.prefix = awaitTag + "-" + strconv.FormatUint(rand.Uint64(), 36) + "-"
+	}
+	s.started++
+	id := s.started
+	renderChild(rw, E(awaitTag, AttrList{{Key: "id", Value: s.awaitID(id)}, {Key: "style", Value: "display:contents"}}, a.fallback))
+
+	ctx := rw.ctx
+	components := append([]ComponentFrame(nil), rw.components...)
+	go func() {
+		var b bytes.Buffer
+		sub := newRenderWriter(ctx, &b)
+		sub.format = newFormatState(s.opts)
+		sub.components = append(sub.components, components...)
+		sub.renderTop(WithContext(a.fn))
+		_, err := sub.finish()
+		select {
+		case s.results <- awaitResult{id: id, body: b.Bytes(), err: err}:
+		case <-s.abandon:
+		}
+	}()
+}
+
+// streamAwaits flushes the page rendered so far and writes each Await's content as it
+// resolves. The first failed Await, cancelled context or write error ends the stream.
+func (rw *renderWriter) streamAwaits() {
+	s := rw.stream
+	if s.started == 0 {
+		return
+	}
+	defer close(s.abandon)
+	rw.resolveHead()
+	rw.flush()
+	for pending := s.started; pending > 0 && rw.ok(); pending-- {
+		var r awaitResult
+		select {
+		case r = <-s.results:
+		case <-rw.done:
+			rw.fail(rw.ctx.Err())
+			return
+		}
+		if r.err != nil {
+			rw.fail(r.err)
+			return
+		}
+		id := s.awaitID(r.id)
+		if s.opts.Stream == StreamOOB {
+			rw.write(`<` + awaitTag + ` id="` + id + `" hx-swap-oob="outerHTML" style="display:contents">`)
+			_, _ = rw.Write(r.body)
+			rw.write(`</` + awaitTag + `>`)
+		} else {
+			if pending == s.started {
+				rw.write(awaitScript)
+			}
+			rw.write(`<template>`)
+			_, _ = rw.Write(r.body)
+			rw.write(`</template><script>gohtmlxAwait("` + id + `")</script>`)
+		}
+		rw.flush()
+	}
+}
+
+// awaitID returns the id of the placeholder of the id'th Await started.
+func (s *stream) awaitID(id int) string {
+	return s.prefix + strconv.Itoa(id)
+}
+
+// flush writes buffered output through to the caller's writer and flushes it when it
+// supports flushing (http.ResponseWriter, *bufio.Writer), so the client receives it now.
+func (rw *renderWriter) flush() {
+	if rw.buf != nil {
+		if err := rw.buf.Flush(); err != nil {
+			rw.fail(err)
+			return
+		}
+	}
+	switch f := rw.w.(type) {
+	case interface{ Flush() error }:
+		if err := f.Flush(); err != nil {
+			rw.fail(err)
+		}
+	case interface{ Flush() }:
+		f.Flush()
+	}
+}
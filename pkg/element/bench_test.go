@@ -0,0 +1,72 @@
+package element
+
+import (
+	"io"
+	"testing"
+)
+
+type benchRow struct {
+	ID    int
+	Name  string
+	Email string
+	Score float64
+	Admin bool
+}
+
+func benchRows(n int) []benchRow {
+	rows := make([]benchRow, n)
+	for i := range rows {
+		rows[i] = benchRow{ID: i, Name: "User <" + string(rune('a'+i%26)) + ">", Email: "user@example.com", Score: float64(i) * 1.5, Admin: i%7 == 0}
+	}
+	return rows
+}
+
+// benchTable mirrors the code the transpiler generates for a <for> over table rows.
+func benchTable(rows []benchRow) Element {
+	return WithContext(func(ctx Context) Element {
+		return R(E(`table`, AttrList{{Key: `class`, Value: `min-w-full divide-y divide-gray-200`}}, R(Literal(`
+  `)), E(`tbody`, AttrList{}, R(func() []Element {
+			resp := []Element{}
+			for _, row := range rows {
+				resp = append(resp, E(`tr`, AttrList{{Key: `id`, Value: R(`row-`, row.ID)}, {Key: `class`, Value: `hover:bg-gray-50`}, {Key: `hidden`, Value: row.Admin}},
+					E(`td`, AttrList{{Key: `class`, Value: `px-4 py-2`}}, R(row.ID)),
+					E(`td`, AttrList{{Key: `class`, Value: `px-4 py-2 font-medium`}}, R(row.Name)),
+					E(`td`, AttrList{{Key: `class`, Value: `px-4 py-2`}}, R(Literal(`<a href="mailto:`), row.Email, Literal(`">mail</a>`))),
+					E(`td`, AttrList{{Key: `class`, Value: `px-4 py-2 text-right`}}, R(row.Score)),
+				))
+			}
+			return resp
+		}())), R(Literal(`
+`))))
+	})
+}
+
+func BenchmarkRender_Table1000(b *testing.B) {
+	el := benchTable(benchRows(1000))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := el.Render(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuildAndRender_Table100(b *testing.B) {
+	rows := benchRows(100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := benchTable(rows).Render(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRender_EscapedText(b *testing.B) {
+	el := E(`p`, nil, R(`Tom & Jerry <3 "quotes" and 'apostrophes' in user input`))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := el.Render(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package element
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStore stores the rendered output of Cached elements. Implementations must be safe for
+// concurrent use; Get must not hand out a slice it later modifies.
+type CacheStore interface {
+	// Get returns the output stored under key, if present and not expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under key for ttl; a ttl of 0 means until evicted.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// DefaultCacheStore is the store Cached uses when the render context has none (see
+// WithCacheStore). Replace it at startup to change the memory bound or use a shared store; nil
+// disables caching.
+var DefaultCacheStore CacheStore = NewMemoryCache(32 << 20)
+
+type cacheKey struct{}
+
+// WithCacheStore returns a context under which Cached elements use store; a nil store renders
+// them uncached.
+func WithCacheStore(ctx Context, store CacheStore) Context {
+	return context.WithValue(ctx, cacheKey{}, &store)
+}
+
+// Cached renders el once and replays its output for key until ttl has passed (0: until the
+// store evicts it; negative: never cached), so content that is the same for every request costs one store lookup.
+// Generated components cache their whole output with a "cache" section:
+//
+//	<!-- | define "cache" -->
+//	key: props.Locale
+//	ttl: 5m
+//	<!-- | end -->
+//
+// The key must include everything the output depends on; build it with CacheKey. The
+// transpiler rejects a cache section on components that use children, slots, caller
+// attributes or ctx, which the key cannot include. el should be lazy (a component or
+// WithContext) so nothing is built on a hit. Output is cached only from plain renders: under
+// Minify or Pretty, inside foreign content, or while RenderFragment searches, el renders
+// normally. Awaits inside el render in place, so cached output is complete; output that
+// renders HeadItems, with or without a HeadOutlet, is not cached. Concurrent misses each
+// render el.
+func Cached(key string, ttl time.Duration, el Element) Element {
+	return cachedElement{key: key, ttl: ttl, el: el}
+}
+
+// CacheKey joins a component name and the values its output depends on into a Cached key.
+func CacheKey(name string, parts ...any) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, p := range parts {
+		b.WriteByte(0)
+		if s, ok := p.(string); ok {
+			b.WriteString(s)
+		} else {
+			fmt.Fprint(&b, p)
+		}
+	}
+	return b.String()
+}
+
+type cachedElement struct {
+	key string
+	ttl time.Duration
+	el  Element
+}
+
+func (c cachedElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, c)
+}
+
+func (c cachedElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, c)
+}
+
+func (c cachedElement) renderTo(rw *renderWriter) {
+	store := DefaultCacheStore
+	if s, ok := rw.ctx.Value(cacheKey{}).(*CacheStore); ok {
+		store = *s
+	}
+	if store == nil || c.ttl < 0 || rw.format != nil || rw.foreign || rw.inAttr || rw.searching() {
+		renderChild(rw, c.el)
+		return
+	}
+	if b, ok := store.Get(c.key); ok {
+		_, _ = rw.Write(b)
+		return
+	}
+	var b bytes.Buffer
+	sub := newRenderWriter(rw.ctx, &b)
+	sub.components = append(sub.components, rw.components...)
+	// HeadItems in el add to the page's head, or render in place without an outlet. Output
+	// that rendered any is not cached: a hit would neither add them nor know whether the page
+	// it is replayed in has an outlet.
+	if rw.head != nil && !rw.head.resolved {
+		sub.head = rw.head
+	}
+	sub.renderTop(c.el)
+	headItems := sub.headItems
+	if _, err := sub.finish(); err != nil {
+		rw.fail(err)
+		return
+	}
+	rw.headItems += headItems
+	if headItems == 0 {
+		store.Set(c.key, b.Bytes(), c.ttl)
+	}
+	_, _ = rw.Write(b.Bytes())
+}
+
+// MemoryCache is an in-memory CacheStore bounded by size, evicting the least recently used
+// entries first.
+type MemoryCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	entries  map[string]*list.Element
+	lru      list.List // of *memoryEntry, most recently used first
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero: never
+}
+
+// NewMemoryCache returns a MemoryCache holding at most maxBytes of keys and values.
+func NewMemoryCache(maxBytes int) *MemoryCache {
+	return &MemoryCache{maxBytes: maxBytes, entries: make(map[string]*list.Element)}
+}
+
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		m.remove(el)
+		return nil, false
+	}
+	m.lru.MoveToFront(el)
+	return e.value, true
+}
+
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	n := len(key) + len(value)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+	if n > m.maxBytes {
+		return
+	}
+	e := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	m.entries[key] = m.lru.PushFront(e)
+	m.size += n
+	for m.size > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet removed.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryCache) remove(el *list.Element) {
+	e := m.lru.Remove(el).(*memoryEntry)
+	delete(m.entries, e.key)
+	m.size -= len(e.key) + len(e.value)
+}
@@ -0,0 +1,172 @@
+package element
+
+import (
+	"context"
+	"html"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/abdheshnayak/gohtmlx/pkg/utils"
+)
+
+// Classes merges class values into one class attribute value. Each part may be a string
+// (space-separated classes), []string, map[string]bool (classes whose value is true, sorted),
+// a pointer to one of those, or nil; any other value (an Element such as the R(...) of a
+// template class with expressions, a Literal, a number) is rendered as an attribute value. Duplicates are dropped; the first occurrence keeps its place.
+// Components use it to combine their own classes with the caller's:
+//
+//	<section class={Classes("py-14 px-8", props.Attrs["class"])}>
+func Classes(parts ...any) string {
+	var names []string
+	for _, p := range parts {
+		names = appendClasses(names, p)
+	}
+	seen := make(map[string]bool, len(names))
+	out := names[:0]
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func appendClasses(names []string, v any) []string {
+	switch v := v.(type) {
+	case nil:
+	case string:
+		names = append(names, strings.Fields(v)...)
+	case *string:
+		if v != nil {
+			names = append(names, strings.Fields(*v)...)
+		}
+	case []string:
+		for _, s := range v {
+			names = append(names, strings.Fields(s)...)
+		}
+	case map[string]bool:
+		keys := make([]string, 0, len(v))
+		for k, on := range v {
+			if on {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		names = append(names, keys...)
+	default:
+		// The rendered text is unescaped: the merged value is escaped when it is written.
+		var b strings.Builder
+		rw := newRenderWriter(context.Background(), &b)
+		renderAttrValue(rw, v)
+		if _, err := rw.finish(); err != nil {
+			utils.Log.Error("unsupported class value", "type", reflect.TypeOf(v).String(), "error", err)
+			break
+		}
+		names = append(names, strings.Fields(html.UnescapeString(b.String()))...)
+	}
+	return names
+}
+
+// classValue returns the class attribute text for the structured forms []string and
+// map[string]bool; ok is false for any other value, which renders as usual.
+func classValue(v any) (s string, ok bool) {
+	switch v.(type) {
+	case []string, map[string]bool:
+		return Classes(v), true
+	}
+	return "", false
+}
+
+// styleValue returns the style attribute text for a map[string]string, as "k:v;" pairs sorted
+// by property name; empty values are skipped. ok is false for any other value.
+func styleValue(v any) (s string, ok bool) {
+	m, ok := v.(map[string]string)
+	if !ok {
+		return "", false
+	}
+	keys := make([]string, 0, len(m))
+	for k, val := range m {
+		if val != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(m[k])
+	}
+	return b.String(), true
+}
+
+// dataAttrs expands a struct or map given as the "data" attribute into data-* attributes.
+// Struct fields are named by their `data:"name"` tag or the kebab-cased field name
+// (UserID becomes data-user-id); `data:"-"` and unexported fields are skipped. Map keys are
+// used as written, sorted. ok is false for any other value, which renders as a plain data
+// attribute.
+func dataAttrs(v any) (list AttrList, ok bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Tag.Get("data")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = kebab(f.Name)
+			}
+			list = append(list, Attr{Key: "data-" + name, Value: rv.Field(i).Interface()})
+		}
+		return list, true
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			list = append(list, Attr{Key: "data-" + k, Value: rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()})
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// kebab converts a Go field name to kebab case: UserID -> user-id, HTMLSafe -> html-safe.
+func kebab(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
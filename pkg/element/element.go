@@ -5,8 +5,10 @@
 package element
 
 import (
-	"fmt"
+	"context"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/abdheshnayak/gohtmlx/pkg/utils"
@@ -20,6 +22,71 @@ const (
 // Used by generated code and by E(tag, attrs, children...).
 type Attrs map[string]any
 
+// Attr is a single attribute of an element.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// AttrList holds attributes in a fixed order. Generated code uses it so elements render
+// attributes in template source order.
+type AttrList []Attr
+
+// AttrSource is accepted by E: AttrList renders in list order, Attrs in sorted key order.
+type AttrSource interface {
+	attrList() AttrList
+}
+
+func (a AttrList) attrList() AttrList {
+	return a
+}
+
+// attrList returns the attributes sorted by key so map-built attributes render deterministically.
+func (a Attrs) attrList() AttrList {
+	if len(a) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make(AttrList, len(keys))
+	for i, k := range keys {
+		list[i] = Attr{Key: k, Value: a[k]}
+	}
+	return list
+}
+
+// Spread merges a component's caller attributes into an element's template attributes; the
+// transpiler emits it for <div {...attrs}>. A caller value replaces the template value of the
+// same key in place, except class, whose values are merged with Classes (template classes
+// first). Remaining caller attributes follow, sorted by key. A caller value of nil removes the
+// attribute, as false does for a boolean attribute.
+func Spread(list AttrList, attrs Attrs) AttrList {
+	if len(attrs) == 0 {
+		return list
+	}
+	out := make(AttrList, 0, len(list)+len(attrs))
+	used := make(map[string]bool, len(attrs))
+	for _, a := range list {
+		if v, ok := attrs[a.Key]; ok {
+			used[a.Key] = true
+			if a.Key == "class" {
+				v = Classes(a.Value, v)
+			}
+			a.Value = v
+		}
+		out = append(out, a)
+	}
+	for _, a := range attrs.attrList() {
+		if !used[a.Key] {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
 // GetAttr returns a typed attribute value from attrs, or nil if missing or wrong type.
 func GetAttr[T any](attrs Attrs, key string) *T {
 	if val, ok := attrs[key]; ok {
@@ -35,6 +102,16 @@ func GetAttr[T any](attrs Attrs, key string) *T {
 	return nil
 }
 
+// Literal is template text emitted by the transpiler. It is already HTML-encoded and is
+// written verbatim; every other string rendered through R or an attribute is escaped.
+// Do not convert user input to Literal.
+type Literal string
+
+// Raw is trusted, pre-rendered markup (e.g. sanitized markdown output or an SVG blob) that is
+// written without escaping. Templates produce it with <raw>{expr}</raw> or {@html expr}; keep
+// its uses few so unsafe output stays easy to find and review.
+type Raw string
+
 // Event is a placeholder type for future event handling.
 type Event any
 
@@ -43,11 +120,112 @@ type Element interface {
 	Render(io.Writer) (int, error)
 }
 
+// Context is context.Context, aliased so generated code can name it through the element import.
+type Context = context.Context
+
+// ContextElement is an Element that can render under a context. Elements built by this package
+// implement it: rendering stops with ctx.Err() once ctx is cancelled, and components built with
+// WithContext receive ctx.
+type ContextElement interface {
+	Element
+	RenderContext(ctx context.Context, w io.Writer) (int, error)
+}
+
+// RenderContext renders el to w under ctx. Use it in handlers with the request context so a
+// disconnected client stops rendering: element.RenderContext(r.Context(), el, w).
+// Render(w) is equivalent to RenderContext(context.Background(), el, w).
+func RenderContext(ctx context.Context, el Element, w io.Writer) (int, error) {
+	if ce, ok := el.(ContextElement); ok {
+		return ce.RenderContext(ctx, w)
+	}
+	rw := newRenderWriter(ctx, w)
+	rw.renderTop(el)
+	return rw.finish()
+}
+
+// Render writes the literal text; Literal can be used directly as a child Element.
+func (l Literal) Render(w io.Writer) (int, error) {
+	return renderRoot(w, l)
+}
+
+func (l Literal) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, l)
+}
+
+func (l Literal) renderTo(rw *renderWriter) {
+	if rw.searching() {
+		return
+	}
+	rw.writeTrusted(string(l))
+}
+
+type contextElement struct {
+	name string
+	file string
+	line int
+	// Exactly one of fn and write is set.
+	fn    func(ctx Context) Element
+	write func(w *Writer)
+}
+
+// WithContext returns an Element whose content is built by fn at render time with the render
+// context (context.Background() under plain Render), so templates can use {ctx} for
+// request-scoped values.
+func WithContext(fn func(ctx Context) Element) Element {
+	return contextElement{fn: fn}
+}
+
+// Component is WithContext for a named component; generated components wrap their body in it.
+// The name is reported to an Instrumenter and used as the pprof label (see WithInstrumenter
+// and WithProfileLabels).
+func Component(name string, fn func(ctx Context) Element) Element {
+	return contextElement{name: name, fn: fn}
+}
+
+// ComponentAt is Component with the .html file and line the component is defined at, which
+// a RenderError reports when the component panics; the line is the component's, not the
+// panicking expression's. Generated components use it.
+func ComponentAt(name, file string, line int, fn func(ctx Context) Element) Element {
+	return contextElement{name: name, file: file, line: line, fn: fn}
+}
+
+func (c contextElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, c)
+}
+
+func (c contextElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, c)
+}
+
+func (c contextElement) renderTo(rw *renderWriter) {
+	if c.name == "" {
+		c.renderContent(rw)
+		return
+	}
+	// A panic leaves the frame in place, so the stack at recovery is the stack at the panic.
+	rw.components = append(rw.components, ComponentFrame{Name: c.name, File: c.file, Line: c.line})
+	if rw.instr != nil {
+		rw.renderComponent(c)
+	} else {
+		c.renderContent(rw)
+	}
+	rw.components = rw.components[:len(rw.components)-1]
+}
+
+// renderContent renders the content of c under the render context of rw.
+func (c contextElement) renderContent(rw *renderWriter) {
+	if c.write != nil {
+		c.write((*Writer)(rw))
+		return
+	}
+	renderChild(rw, c.fn(rw.ctx))
+}
+
 type element struct {
 	tag       string
 	childrens []Element
 
-	attrs Attrs
+	attrs AttrList
 }
 
 type renderElement struct {
@@ -55,61 +233,62 @@ type renderElement struct {
 }
 
 func (t renderElement) Render(w io.Writer) (int, error) {
-	var buffer strings.Builder
+	return renderRoot(w, t)
+}
 
+func (t renderElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, t)
+}
+
+func (t renderElement) renderTo(rw *renderWriter) {
 	for _, item := range t.items {
-		switch item := item.(type) {
-		case int:
-			buffer.WriteString(fmt.Sprintf("%d", item))
-		case float64:
-			buffer.WriteString(fmt.Sprintf("%f", item))
-		case bool:
-			buffer.WriteString(fmt.Sprintf("%t", item))
-		case *string:
-			buffer.WriteString(*item)
-		case *int:
-			buffer.WriteString(fmt.Sprintf("%d", *item))
-		case *float64:
-			buffer.WriteString(fmt.Sprintf("%f", *item))
-		case *bool:
-			buffer.WriteString(fmt.Sprintf("%t", *item))
-		case *Element:
-			_, _ = (*item).Render(&buffer)
-		case *[]Element:
-			for _, child := range *item {
-				_, _ = child.Render(&buffer)
-			}
-		case string:
-			buffer.WriteString(strings.ReplaceAll(item, nbspChar, "&nbsp;"))
-			// buffer.WriteString(item.(string))
+		if !rw.ok() {
+			return
+		}
+		writeItem(rw, item)
+	}
+}
 
+// writeItem writes one R item with the writeValue table.
+func writeItem(rw *renderWriter, item any) {
+	if rw.searching() {
+		// Looking for a fragment: only elements can contain it.
+		switch item := item.(type) {
 		case Element:
-			_, _ = item.Render(&buffer)
+			renderChild(rw, item)
 		case []Element:
 			for _, child := range item {
-				_, _ = child.Render(&buffer)
+				renderChild(rw, child)
 			}
-		default:
-			utils.Log.Error("error", "for", fmt.Sprintf("%v", item))
-			buffer.WriteString(fmt.Sprintf("%v", item))
 		}
+		return
 	}
-
-	return w.Write([]byte(buffer.String()))
+	writeValue(rw, item)
 }
 
 // R builds an Element from a mix of strings, Elements, and slices of Elements (used by generated code).
 func R(items ...interface{}) Element {
+	if len(items) == 1 {
+		// A lone Element needs no wrapper; this saves an allocation per R(E(...)) and R(Literal(...)).
+		if el, ok := items[0].(Element); ok {
+			return el
+		}
+	}
 	return renderElement{
 		items: items,
 	}
 }
 
 // E builds an HTML element with the given tag, attrs, and children (used by generated code).
-func E(tag string, attrs Attrs, childrens ...Element) Element {
+// attrs may be an AttrList (source order) or Attrs (sorted by key); nil means no attributes.
+func E(tag string, attrs AttrSource, childrens ...Element) Element {
+	var list AttrList
+	if attrs != nil {
+		list = attrs.attrList()
+	}
 	return element{
 		tag:       tag,
-		attrs:     attrs,
+		attrs:     list,
 		childrens: childrens,
 	}
 }
@@ -125,40 +304,183 @@ func RenderE(element string, props ...interface{}) Element {
 }
 
 func (e element) Render(w io.Writer) (int, error) {
-	var buffer strings.Builder
-	buffer.WriteString("<")
-	buffer.WriteString(e.tag)
-	for k, v := range e.attrs {
-		buffer.WriteString(" ")
-		buffer.WriteString(k)
-		buffer.WriteString("=\"")
-
-		switch v := v.(type) {
-		case string:
-			buffer.WriteString(v)
-		case *string:
-			buffer.WriteString(*v)
-		case Element:
-			_, _ = v.Render(&buffer)
-		case []Element:
-			for _, child := range v {
-				_, _ = child.Render(&buffer)
+	return renderRoot(w, e)
+}
+
+func (e element) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, e)
+}
+
+func (e element) renderTo(rw *renderWriter) {
+	if rw.searching() {
+		if e.matchesFragment(rw) {
+			rw.renderFragment(e)
+			return
+		}
+		for _, child := range e.childrens {
+			if !rw.ok() {
+				return
 			}
-		default:
-			utils.Log.Error("unknown type", "for", fmt.Sprintf("%v", v))
-			buffer.WriteString(fmt.Sprintf("%v", v))
+			renderChild(rw, child)
 		}
-
-		buffer.WriteString("\"")
+		return
+	}
+	hasChildren := len(e.childrens) > 0
+	hasEnd, foreign := rw.beginTag(e.tag, hasChildren)
+	for _, a := range e.attrs {
+		rw.writeElementAttr(a)
+	}
+	if !rw.endStartTag(e.tag, hasEnd, foreign, hasChildren) {
+		return
 	}
 
-	buffer.WriteString(">")
+	prev := rw.foreign
+	rw.foreign = foreign && e.tag != "foreignObject"
 	for _, child := range e.childrens {
-		_, _ = child.Render(&buffer)
+		if !rw.ok() {
+			return
+		}
+		renderChild(rw, child)
 	}
-	buffer.WriteString("</")
-	buffer.WriteString(e.tag)
-	buffer.WriteString(">")
+	rw.foreign = prev
+	rw.endTag(e.tag)
+}
+
+// beginTag writes "<tag" and reports whether the element has content and an end tag, and
+// whether it is foreign content. Attributes follow, then endStartTag.
+func (rw *renderWriter) beginTag(tag string, hasChildren bool) (hasEnd, foreign bool) {
+	foreign = rw.foreign || isForeignRoot(tag)
+	hasEnd = foreign && hasChildren || !foreign && !IsVoid(tag)
+	if rw.format != nil {
+		rw.openTag(tag, hasEnd)
+	}
+	rw.inTag = true
+	rw.write("<")
+	rw.write(tag)
+	return hasEnd, foreign
+}
+
+// writeElementAttr writes one attribute of a start tag, expanding class, style and data.
+func (rw *renderWriter) writeElementAttr(a Attr) {
+	switch a.Key {
+	case "class":
+		if s, ok := classValue(a.Value); ok {
+			if s != "" {
+				writeAttr(rw, a.Key, s)
+			}
+			return
+		}
+	case "style":
+		if s, ok := styleValue(a.Value); ok {
+			if s != "" {
+				writeAttr(rw, a.Key, s)
+			}
+			return
+		}
+	case "data":
+		if list, ok := dataAttrs(a.Value); ok {
+			for _, d := range list {
+				writeAttr(rw, d.Key, d.Value)
+			}
+			return
+		}
+	}
+	writeAttr(rw, a.Key, a.Value)
+}
+
+// endStartTag closes the start tag and reports whether content and an end tag follow.
+func (rw *renderWriter) endStartTag(tag string, hasEnd, foreign, hasChildren bool) bool {
+	rw.inTag = false
+	if !hasEnd {
+		if foreign {
+			rw.write("/>")
+			return false
+		}
+		rw.write(">")
+		if hasChildren {
+			utils.Log.Error("void element cannot have children", "tag", tag)
+		}
+		return false
+	}
+	rw.write(">")
+	if rw.format != nil {
+		rw.startContent(tag)
+	}
+	return true
+}
+
+func (rw *renderWriter) endTag(tag string) {
+	if rw.format != nil {
+		rw.closeTag(tag)
+	}
+	rw.write("</")
+	rw.write(tag)
+	rw.write(">")
+}
+
+// writeAttr writes one attribute with a leading space. Boolean attributes: true renders the bare
+// name, false and nil omit the attribute. Attributes whose values are "true" and "false"
+// (aria-*, spellcheck, ...) render a bool as that text instead.
+func writeAttr(rw *renderWriter, k string, v any) {
+	if trueFalseAttr(k) {
+		switch b := v.(type) {
+		case bool:
+			v = strconv.FormatBool(b)
+		case *bool:
+			if b == nil {
+				return
+			}
+			v = strconv.FormatBool(*b)
+		}
+	}
+	switch b := v.(type) {
+	case nil:
+		return
+	case bool:
+		if b {
+			rw.write(" ")
+			rw.write(k)
+		}
+		return
+	case *bool:
+		if b != nil && *b {
+			rw.write(" ")
+			rw.write(k)
+		}
+		return
+	}
+
+	rw.write(" ")
+	rw.write(k)
+	rw.write("=\"")
+	switch v := v.(type) {
+	case string:
+		rw.writeEscaped(v)
+	case Raw:
+		rw.writeEscapedSet(string(v), true)
+	default:
+		renderAttrValue(rw, v)
+	}
+	rw.write("\"")
+}
+
+// trueFalseAttr reports whether attribute k is an enumerated attribute with the values "true"
+// and "false", where a missing attribute means neither: ARIA states and properties, and
+// spellcheck, draggable, contenteditable and writingsuggestions.
+func trueFalseAttr(k string) bool {
+	switch k {
+	case "spellcheck", "draggable", "contenteditable", "writingsuggestions":
+		return true
+	}
+	return strings.HasPrefix(k, "aria-")
+}
 
-	return w.Write([]byte(buffer.String()))
+// renderAttrValue renders v as an attribute value using the writeValue table. Dynamic text is
+// escaped as usual; template text has its double quotes encoded so it cannot end the attribute,
+// and slice items are separated by spaces.
+func renderAttrValue(rw *renderWriter, v any) {
+	prev := rw.inAttr
+	rw.inAttr = true
+	writeValue(rw, v)
+	rw.inAttr = prev
 }
@@ -0,0 +1,1044 @@
+package element
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/url"
+	"regexp"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func renderString(t *testing.T, el Element) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := el.Render(&b); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return b.String()
+}
+
+func TestRender_EscapesDynamicText(t *testing.T) {
+	msg := `<script>alert("x")</script> & 'y'`
+	got := renderString(t, E(`p`, Attrs{}, R(Literal(`Hi <b>`), msg, &msg)))
+	want := `<p>Hi <b>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &#39;y&#39;&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &#39;y&#39;</p>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRender_EscapesAttributeValues(t *testing.T) {
+	v := `x" onmouseover="alert(1)`
+	got := renderString(t, E(`a`, Attrs{`title`: v}))
+	want := `<a title="x&#34; onmouseover=&#34;alert(1)"></a>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// Mixed literal/expression values are built with R(...) and must not be double-escaped.
+	got = renderString(t, E(`a`, Attrs{`class`: R(`btn `, `a&b"`)}))
+	want = `<a class="btn a&amp;b&#34;"></a>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestNewHtml_TextEmittedAsLiteral(t *testing.T) {
+	h, err := NewHtml([]byte("<p>a &lt; b {props.Name}</p>"))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.Contains(out, "Literal(`a &lt; b `)") {
+		t.Errorf("expected re-encoded Literal for template text, got: %s", out)
+	}
+	if strings.Contains(out, "Literal(props.Name") {
+		t.Errorf("expressions must not be wrapped in Literal, got: %s", out)
+	}
+}
+
+func TestRender_RawPassesThrough(t *testing.T) {
+	body := "<em>trusted</em>"
+	got := renderString(t, E(`div`, Attrs{}, R(Raw(body), body)))
+	want := `<div><em>trusted</em>&lt;em&gt;trusted&lt;/em&gt;</div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRender_VoidElements(t *testing.T) {
+	got := renderString(t, E(`div`, Attrs{}, E(`meta`, Attrs{`charset`: `UTF-8`}), E(`br`, Attrs{}), E(`input`, Attrs{}, R(`ignored`))))
+	want := `<div><meta charset="UTF-8"><br><input></div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRender_BooleanAttributes(t *testing.T) {
+	on, off := true, false
+	var missing *bool
+	got := renderString(t, E(`input`, Attrs{`disabled`: true, `checked`: false, `hidden`: nil, `required`: &on, `readonly`: &off, `autofocus`: missing}))
+	want := `<input disabled required>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// ARIA and enumerated attributes take "true" and "false"; a missing one means neither.
+	got = renderString(t, E(`div`, AttrList{{Key: `aria-expanded`, Value: false}, {Key: `aria-hidden`, Value: &on},
+		{Key: `draggable`, Value: false}, {Key: `spellcheck`, Value: true}, {Key: `aria-busy`, Value: missing}}))
+	want = `<div aria-expanded="false" aria-hidden="true" draggable="false" spellcheck="true"></div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRender_AttributeOrder(t *testing.T) {
+	list := AttrList{{Key: `type`, Value: `button`}, {Key: `class`, Value: `btn`}, {Key: `aria-label`, Value: `Close`}}
+	got := renderString(t, E(`button`, list))
+	want := `<button type="button" class="btn" aria-label="Close"></button>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// Attrs maps render in sorted key order, on every call.
+	m := Attrs{`type`: `button`, `class`: `btn`, `aria-label`: `Close`, `id`: `x`}
+	want = `<button aria-label="Close" class="btn" id="x" type="button"></button>`
+	for i := 0; i < 20; i++ {
+		if got := renderString(t, E(`button`, m)); got != want {
+			t.Fatalf("got  %s\nwant %s", got, want)
+		}
+	}
+	if got := renderString(t, E(`p`, nil)); got != `<p></p>` {
+		t.Errorf("nil attrs: got %s", got)
+	}
+}
+
+type failingWriter struct {
+	limit       int
+	failed      bool
+	afterFailed int
+	n           int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.failed {
+		f.afterFailed++
+	}
+	if f.n+len(p) > f.limit {
+		f.failed = true
+		return 0, errors.New("connection reset")
+	}
+	f.n += len(p)
+	return len(p), nil
+}
+
+func TestRender_StreamsAndStopsOnWriteError(t *testing.T) {
+	rows := make([]Element, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		rows = append(rows, E(`tr`, nil, E(`td`, nil, R(`cell`))))
+	}
+	page := E(`table`, nil, R(rows))
+
+	var b strings.Builder
+	n, err := page.Render(&b)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if n != b.Len() {
+		t.Errorf("Render returned %d bytes, wrote %d", n, b.Len())
+	}
+
+	fw := &failingWriter{limit: 10000}
+	n, err = page.Render(fw)
+	if err == nil {
+		t.Fatal("expected write error to be returned")
+	}
+	if n != fw.n {
+		t.Errorf("Render returned %d bytes, writer accepted %d", n, fw.n)
+	}
+	if fw.afterFailed > 0 {
+		t.Errorf("rendering should stop after the first failed write, got %d more writes", fw.afterFailed)
+	}
+}
+
+type ctxKey struct{}
+
+func TestRenderContext_PassesContextAndStopsOnCancel(t *testing.T) {
+	greet := WithContext(func(ctx Context) Element {
+		name, _ := ctx.Value(ctxKey{}).(string)
+		return E(`p`, nil, R(name))
+	})
+	ctx := context.WithValue(context.Background(), ctxKey{}, "Ada")
+	var b strings.Builder
+	if _, err := RenderContext(ctx, E(`div`, nil, greet), &b); err != nil {
+		t.Fatalf("RenderContext: %v", err)
+	}
+	if got, want := b.String(), `<div><p>Ada</p></div>`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	// Plain Render still works; the component sees a background context.
+	if got, want := renderString(t, greet), `<p></p>`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rendered := 0
+	rows := make([]Element, 0, 100)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, WithContext(func(ctx Context) Element {
+			rendered++
+			if rendered == 10 {
+				cancel()
+			}
+			return E(`tr`, nil)
+		}))
+	}
+	_, err := RenderContext(ctx, E(`table`, nil, rows...), io.Discard)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if rendered != 10 {
+		t.Errorf("expected rendering to stop after cancel, rendered %d rows", rendered)
+	}
+}
+
+type userID int64
+
+type status string
+
+type celsius float32
+
+type point struct{ X, Y int }
+
+func (p point) String() string { return fmt.Sprintf("(%d, %d)", p.X, p.Y) }
+
+func TestRender_ValueConversionTable(t *testing.T) {
+	n := 7
+	var nilPtr *int
+	var nilURL *url.URL
+	var nilErr *fs.PathError
+	when := time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   any
+		want string
+	}{
+		{"nil", nil, ``},
+		{"nil pointer", nilPtr, ``},
+		{"pointer", &n, `7`},
+		{"int8", int8(-8), `-8`},
+		{"int64", int64(1) << 40, `1099511627776`},
+		{"uint", uint(42), `42`},
+		{"uint64", uint64(18446744073709551615), `18446744073709551615`},
+		{"float64", 1.5, `1.5`},
+		{"float64 integral", 100.0, `100`},
+		{"float64 small", 0.0000001, `1e-7`},
+		{"float64 large", 1e21, `1e+21`},
+		{"float32", float32(0.1), `0.1`},
+		{"NaN", math.NaN(), `NaN`},
+		{"complex", complex(1, 2), `(1+2i)`},
+		{"bool", false, `false`},
+		{"bytes", []byte("a<b"), `a&lt;b`},
+		{"named int", userID(12), `12`},
+		{"named string", status(`<ok>`), `&lt;ok&gt;`},
+		{"named float", celsius(21.5), `21.5`},
+		{"time", when, `2024-03-09T14:05:00Z`},
+		{"time with nanoseconds", when.Add(1500 * time.Millisecond), `2024-03-09T14:05:01.5Z`},
+		{"error", errors.New(`bad & worse`), `bad &amp; worse`},
+		{"stringer", point{1, 2}, `(1, 2)`},
+		{"nil pointer stringer", nilURL, ``},
+		{"nil pointer error", nilErr, ``},
+		{"nil pointer time", (*time.Time)(nil), ``},
+		{"duration stringer", 1500 * time.Millisecond, `1.5s`},
+		{"strings", []string{`a`, `<b>`}, `a&lt;b&gt;`},
+		{"ints", []int{1, 2, 3}, `123`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderString(t, R(tt.in)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender_AttributeValueConversion(t *testing.T) {
+	got := renderString(t, E(`td`, AttrList{
+		{Key: `data-id`, Value: userID(3)},
+		{Key: `data-ratio`, Value: 0.25},
+		{Key: `class`, Value: []string{`a`, `b"c`}},
+		{Key: `datetime`, Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}))
+	want := `<td data-id="3" data-ratio="0.25" class="a b&#34;c" datetime="2024-01-02T03:04:05Z"></td>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRender_StructuredClassStyleAndData(t *testing.T) {
+	type card struct {
+		UserID   int
+		Kind     string `data:"type"`
+		Selected bool
+		Secret   string `data:"-"`
+		internal int
+	}
+	got := renderString(t, E(`div`, AttrList{
+		{Key: `class`, Value: []string{`card`, `p-4`, `card`}},
+		{Key: `style`, Value: map[string]string{`margin`: `0`, `color`: `red`, `width`: ``}},
+		{Key: `data`, Value: card{UserID: 7, Kind: `<a>`, Selected: true, Secret: `s`}},
+	}))
+	want := `<div class="card p-4" style="color:red;margin:0" data-user-id="7" data-type="&lt;a&gt;" data-selected></div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	got = renderString(t, E(`span`, Attrs{
+		`class`: map[string]bool{`on`: true, `off`: false, `active`: true},
+		`data`:  map[string]any{`b`: 2, `a`: `x`},
+	}))
+	want = `<span class="active on" data-a="x" data-b="2"></span>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// An empty class list omits the attribute; a plain data value is an ordinary attribute.
+	got = renderString(t, E(`i`, AttrList{{Key: `class`, Value: map[string]bool{`x`: false}}, {Key: `data`, Value: `v`}}))
+	if want := `<i data="v"></i>`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestClasses_MergesCallerClasses(t *testing.T) {
+	caller := Attrs{`class`: `mt-4 px-8`}
+	got := Classes(`py-14 px-8`, caller[`class`], nil, []string{`rounded`}, map[string]bool{`dark`: true, `hidden`: false})
+	if want := `py-14 px-8 mt-4 rounded dark`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := Classes(); got != `` {
+		t.Errorf("Classes() = %q, want empty", got)
+	}
+}
+
+func TestSpread_CallerAttrsOverrideAndMergeClass(t *testing.T) {
+	caller := Attrs{`class`: `mt-4 card`, `id`: `main`, `hx-get`: `/x`, `aria-label`: `Card`, `title`: nil}
+	got := renderString(t, E(`div`, Spread(AttrList{
+		{Key: `class`, Value: `card p-4`},
+		{Key: `id`, Value: `tmpl`},
+		{Key: `title`, Value: `t`},
+	}, caller)))
+	want := `<div class="card p-4 mt-4" id="main" aria-label="Card" hx-get="/x"></div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// A template class with an expression is an Element; it is merged, not dropped.
+	got = renderString(t, E(`div`, Spread(AttrList{{Key: `class`, Value: R(Literal(`card `), `blue`)}}, Attrs{`class`: `mt-4`})))
+	if want := `<div class="card blue mt-4"></div>`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	list := AttrList{{Key: `id`, Value: `a`}}
+	if got := Spread(list, Attrs{}); len(got) != 1 || got[0].Value != `a` {
+		t.Errorf("Spread with no caller attrs = %v, want template attrs", got)
+	}
+}
+
+func TestRender_ForeignContentSelfCloses(t *testing.T) {
+	got := renderString(t, E(`div`, nil,
+		E(`svg`, AttrList{{Key: `viewBox`, Value: `0 0 8 8`}},
+			E(`path`, AttrList{{Key: `d`, Value: `M0 0`}}),
+			E(`foreignObject`, nil, E(`p`, nil), E(`br`, nil)),
+		),
+		E(`math`, nil, E(`mspace`, nil)),
+		E(`span`, nil),
+	))
+	want := `<div><svg viewBox="0 0 8 8"><path d="M0 0"/><foreignObject><p></p><br></foreignObject></svg><math><mspace/></math><span></span></div>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func renderWithOptions(t *testing.T, el Element, opts *RenderOptions) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := RenderWithOptions(context.Background(), el, &b, opts); err != nil {
+		t.Fatalf("RenderWithOptions: %v", err)
+	}
+	return b.String()
+}
+
+func formatTestPage() Element {
+	return R(Literal("<!DOCTYPE html>"), E(`html`, nil, Literal("\n  "),
+		E(`head`, nil, Literal("\n    "), E(`meta`, AttrList{{Key: `charset`, Value: `UTF-8`}}), Literal("\n    "),
+			E(`title`, nil, Literal("  Hi  ")), Literal("\n  ")),
+		Literal("\n  "),
+		E(`body`, nil, Literal("\n    "),
+			E(`div`, AttrList{{Key: `class`, Value: `a  b`}}, Literal("\n      "),
+				E(`p`, nil, Literal("Hello,\n        "), E(`b`, nil, R("big   world")), Literal(" "), E(`i`, nil, Literal("x")), Literal("!\n      ")),
+				Literal("\n      "),
+				E(`pre`, nil, Literal("  keep\n    this  ")),
+				Literal("\n      "),
+				E(`textarea`, nil, Literal(" a\n b ")), Literal(" "), R(42), Literal("\n    ")),
+			Literal("\n  ")),
+		Literal("\n")))
+}
+
+func TestRenderWithOptions_Minify(t *testing.T) {
+	got := renderWithOptions(t, formatTestPage(), &RenderOptions{Minify: true})
+	want := `<!DOCTYPE html><html><head><meta charset="UTF-8"><title>Hi</title></head><body><div class="a  b"><p>Hello, <b>big world</b> <i>x</i>!</p><pre>  keep` + "\n" + `    this  </pre><textarea> a` + "\n" + ` b </textarea> 42</div></body></html>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRenderWithOptions_Pretty(t *testing.T) {
+	got := renderWithOptions(t, formatTestPage(), &RenderOptions{Pretty: true})
+	want := `<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8">
+    <title>Hi</title>
+  </head>
+  <body>
+    <div class="a  b">
+      <p>Hello, <b>big world</b> <i>x</i>!</p>
+      <pre>  keep
+    this  </pre>
+      <textarea> a
+ b </textarea> 42
+    </div>
+  </body>
+</html>`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without options the template's whitespace is kept.
+	if got, want := renderWithOptions(t, E(`p`, nil, Literal(" a  b ")), nil), `<p> a  b </p>`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestRenderFragment(t *testing.T) {
+	rows := []string{`a`, `b&c`}
+	page := func() Element {
+		return WithContext(func(ctx Context) Element {
+			return E(`main`, nil, Literal(`<h1>Title</h1>`),
+				E(`div`, AttrList{{Key: `id`, Value: `time-result`}}, R(`now`)),
+				Fragment(`rows`, E(`ul`, nil, R(func() []Element {
+					var out []Element
+					for i, r := range rows {
+						out = append(out, E(`li`, AttrList{{Key: `id`, Value: R(Literal(`row-`), i)}}, R(r)))
+					}
+					return out
+				}()))),
+				E(`p`, AttrList{{Key: `id`, Value: `time-result`}}, Literal(`second match`)),
+			)
+		})
+	}
+	tests := []struct{ name, want string }{
+		{`time-result`, `<div id="time-result">now</div>`},
+		{`rows`, `<ul><li id="row-0">a</li><li id="row-1">b&amp;c</li></ul>`},
+		{`row-1`, `<li id="row-1">b&amp;c</li>`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		n, err := RenderFragment(page(), tt.name, &b)
+		if err != nil {
+			t.Fatalf("RenderFragment(%q): %v", tt.name, err)
+		}
+		if b.String() != tt.want || n != len(tt.want) {
+			t.Errorf("RenderFragment(%q) = %q (%d bytes), want %q", tt.name, b.String(), n, tt.want)
+		}
+	}
+
+	var b strings.Builder
+	if _, err := RenderFragment(page(), `missing`, &b); !errors.Is(err, ErrFragmentNotFound) || b.Len() != 0 {
+		t.Errorf("got %q, %v; want no output and ErrFragmentNotFound", b.String(), err)
+	}
+
+	// Outside RenderFragment the marker renders its element as is.
+	if got, want := renderString(t, Fragment(`x`, E(`b`, nil, Literal(`y`)))), `<b>y</b>`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+type spanKey struct{}
+
+type recordingInstrumenter struct {
+	events []string
+}
+
+func (r *recordingInstrumenter) ComponentStart(ctx Context, name string) Context {
+	r.events = append(r.events, "start "+name)
+	return context.WithValue(ctx, spanKey{}, name)
+}
+
+func (r *recordingInstrumenter) ComponentEnd(ctx Context, name string, elapsed time.Duration, bytes int) {
+	if ctx.Value(spanKey{}) != name || elapsed < 0 {
+		r.events = append(r.events, "bad end "+name)
+		return
+	}
+	r.events = append(r.events, fmt.Sprintf("end %s %d", name, bytes))
+}
+
+func TestComponent_Instrumenter(t *testing.T) {
+	var parentSeen any
+	child := func() Element {
+		return Component("Child", func(ctx Context) Element {
+			parentSeen = ctx.Value(spanKey{})
+			return E(`b`, nil, Literal(`x`))
+		})
+	}
+	page := Component("Page", func(ctx Context) Element {
+		return E(`div`, nil, child(), WithContext(func(ctx Context) Element { return Literal(`!`) }))
+	})
+
+	rec := &recordingInstrumenter{}
+	var b strings.Builder
+	if _, err := RenderContext(WithInstrumenter(context.Background(), rec), page, &b); err != nil {
+		t.Fatalf("RenderContext: %v", err)
+	}
+	if got, want := b.String(), `<div><b>x</b>!</div>`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	want := []string{"start Page", "start Child", "end Child 8", "end Page 20"}
+	if strings.Join(rec.events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", rec.events, want)
+	}
+	if parentSeen != "Child" {
+		t.Errorf("component should render under the context from ComponentStart, got %v", parentSeen)
+	}
+
+	// Without an instrumenter, components render as usual.
+	if got := renderString(t, page); got != `<div><b>x</b>!</div>` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestComponent_ProfileLabels(t *testing.T) {
+	var label string
+	page := Component("Page", func(ctx Context) Element {
+		label, _ = pprof.Label(ctx, ProfileLabel)
+		return Literal(`ok`)
+	})
+	var b strings.Builder
+	if _, err := RenderContext(WithProfileLabels(context.Background()), page, &b); err != nil {
+		t.Fatalf("RenderContext: %v", err)
+	}
+	if label != "Page" {
+		t.Errorf("pprof label = %q, want Page", label)
+	}
+}
+
+func TestRender_PanicIsRenderError(t *testing.T) {
+	type user struct{ Name string }
+	var u *user
+	navItem := ComponentAt("NavItem", "comps/header.html", 12, func(ctx Context) Element {
+		return E(`li`, nil, R(u.Name))
+	})
+	header := ComponentAt("AppHeader", "comps/header.html", 1, func(ctx Context) Element {
+		return E(`ul`, nil, navItem)
+	})
+	home := ComponentAt("Home", "comps/home.html", 1, func(ctx Context) Element {
+		return E(`main`, nil, Component("Sidebar", func(ctx Context) Element { return nil }), header)
+	})
+
+	var b strings.Builder
+	_, err := home.Render(&b)
+	var re *RenderError
+	if !errors.As(err, &re) {
+		t.Fatalf("err = %v, want *RenderError", err)
+	}
+	want := "element: panic in Home > AppHeader > NavItem (comps/header.html:12): " +
+		"runtime error: invalid memory address or nil pointer dereference"
+	if re.Error() != want {
+		t.Errorf("Error() = %q, want %q", re.Error(), want)
+	}
+	if len(re.Components) != 3 || re.Components[2] != (ComponentFrame{"NavItem", "comps/header.html", 12}) {
+		t.Errorf("Components = %v", re.Components)
+	}
+	if len(re.Stack) == 0 {
+		t.Error("Stack is empty")
+	}
+	var rte interface{ RuntimeError() }
+	if !errors.As(err, &rte) {
+		t.Error("RenderError should unwrap to the runtime error")
+	}
+
+	// The writer is reusable: the next render starts with an empty stack.
+	if got := renderString(t, E(`p`, nil, Component("Ok", func(ctx Context) Element { return Literal(`ok`) }))); got != `<p>ok</p>` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestRender_PanicOutsideComponent(t *testing.T) {
+	var b strings.Builder
+	_, err := RenderWithOptions(context.Background(), E(`p`, nil, WithContext(func(ctx Context) Element {
+		panic("boom")
+	})), &b, &RenderOptions{Minify: true})
+	if err == nil || err.Error() != "element: panic during render: boom" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSetRepanic(t *testing.T) {
+	SetRepanic(true)
+	defer SetRepanic(false)
+	defer func() {
+		re, ok := recover().(*RenderError)
+		if !ok || len(re.Components) != 1 || re.Components[0].Name != "Page" {
+			t.Errorf("recovered %v, want *RenderError for Page", re)
+		}
+	}()
+	_, _ = Component("Page", func(ctx Context) Element { panic("boom") }).Render(io.Discard)
+	t.Error("Render should panic under SetRepanic(true)")
+}
+
+// flushRecorder records what had been written at each Flush.
+type flushRecorder struct {
+	strings.Builder
+	flushes []string
+	flushed chan struct{}
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes = append(f.flushes, f.String())
+	if len(f.flushes) == 1 {
+		close(f.flushed)
+	}
+}
+
+func TestAwait_Streams(t *testing.T) {
+	w := &flushRecorder{flushed: make(chan struct{})}
+	second := make(chan struct{})
+	page := E(`main`, nil,
+		Await(Literal(`wait`), func(ctx Context) Element {
+			<-second
+			return Literal(`slow`)
+		}),
+		Await(nil, func(ctx Context) Element {
+			<-w.flushed // resolves only after the page was flushed
+			defer close(second)
+			return E(`b`, nil, Literal(`fast`))
+		}),
+	)
+	if _, err := RenderWithOptions(context.Background(), page, w, &RenderOptions{Stream: StreamScript}); err != nil {
+		t.Fatalf("RenderWithOptions: %v", err)
+	}
+	prefix := awaitPrefix(t, w.String())
+	shell := `<main><gohtmlx-await id="` + prefix + `1" style="display:contents">wait</gohtmlx-await>` +
+		`<gohtmlx-await id="` + prefix + `2" style="display:contents"></gohtmlx-await></main>`
+	if w.flushes[0] != shell {
+		t.Errorf("first flush = %s, want %s", w.flushes[0], shell)
+	}
+	want := shell + awaitScript +
+		`<template><b>fast</b></template><script>gohtmlxAwait("` + prefix + `2")</script>` +
+		`<template>slow</template><script>gohtmlxAwait("` + prefix + `1")</script>`
+	if got := w.String(); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+	if len(w.flushes) != 3 {
+		t.Errorf("flushes = %d, want one per write", len(w.flushes))
+	}
+}
+
+// awaitPrefix returns the placeholder id prefix of a streamed render.
+func awaitPrefix(t *testing.T, out string) string {
+	t.Helper()
+	m := regexp.MustCompile(`id="(gohtmlx-await-[0-9a-z]+-)1"`).FindStringSubmatch(out)
+	if m == nil {
+		t.Fatalf("no placeholder in %s", out)
+	}
+	return m[1]
+}
+
+func TestAwait_OOBAndInPlace(t *testing.T) {
+	page := E(`div`, nil,
+		Await(Literal(`...`), func(ctx Context) Element { return Literal(`done`) }),
+		Await(nil, func(ctx Context) Element { return E(`b`, nil, Literal(`more`)) }),
+	)
+	render := func() []*html.Node {
+		var b strings.Builder
+		if _, err := RenderWithOptions(context.Background(), page, &b, &RenderOptions{Stream: StreamOOB}); err != nil {
+			t.Fatalf("RenderWithOptions: %v", err)
+		}
+		nodes, err := ParseOutput(b.String())
+		if err != nil {
+			t.Fatalf("ParseOutput: %v", err)
+		}
+		return nodes
+	}
+	id := func(n *html.Node) string {
+		for _, a := range n.Attr {
+			if a.Key == "id" {
+				return a.Val
+			}
+		}
+		return ""
+	}
+	text := func(n *html.Node) string {
+		var b strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			_ = html.Render(&b, c)
+		}
+		return b.String()
+	}
+
+	seen := map[string]bool{}
+	for range 2 {
+		nodes := render()
+		// The page comes first with its placeholders, then one out-of-band swap per Await
+		// that replaces its placeholder, so a client swapping chunks as they arrive has the
+		// placeholder in the page before the swap.
+		if len(nodes) != 3 || nodes[0].Data != "div" {
+			t.Fatalf("got %d top-level nodes, want the page and 2 swaps", len(nodes))
+		}
+		placeholders := map[string]string{}
+		for c := nodes[0].FirstChild; c != nil; c = c.NextSibling {
+			placeholders[id(c)] = text(c)
+		}
+		for _, n := range nodes[1:] {
+			fallback, ok := placeholders[id(n)]
+			if !ok || seen[id(n)] || !slices.Contains(n.Attr, html.Attribute{Key: "hx-swap-oob", Val: "outerHTML"}) {
+				t.Errorf("swap %s does not replace a placeholder of its own render (placeholders %v)", id(n), placeholders)
+			}
+			if want := map[string]string{"...": "done", "": "<b>more</b>"}[fallback]; text(n) != want {
+				t.Errorf("swap %s has %q, want %q", id(n), text(n), want)
+			}
+			delete(placeholders, id(n))
+			seen[id(n)] = true
+		}
+		if len(placeholders) != 0 {
+			t.Errorf("placeholders %v are never swapped", placeholders)
+		}
+	}
+
+	// Without streaming the content renders in place.
+	if got := renderString(t, page); got != `<div>done<b>more</b></div>` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestAwait_PanicIsRenderError(t *testing.T) {
+	page := Component("Dashboard", func(ctx Context) Element {
+		return Await(nil, func(ctx Context) Element { panic("db down") })
+	})
+	_, err := RenderWithOptions(context.Background(), page, io.Discard, &RenderOptions{Stream: StreamScript})
+	var re *RenderError
+	if !errors.As(err, &re) || len(re.Components) != 1 || re.Components[0].Name != "Dashboard" {
+		t.Errorf("err = %v, want RenderError in Dashboard", err)
+	}
+}
+
+func TestCached(t *testing.T) {
+	store := NewMemoryCache(1 << 10)
+	ctx := WithCacheStore(context.Background(), store)
+	builds := 0
+	footer := func(locale string) Element {
+		return Cached(CacheKey("Footer", locale), 0, WithContext(func(ctx Context) Element {
+			builds++
+			return E(`footer`, nil, Literal(locale))
+		}))
+	}
+	render := func(ctx Context, el Element) string {
+		var b strings.Builder
+		if _, err := RenderContext(ctx, el, &b); err != nil {
+			t.Fatalf("RenderContext: %v", err)
+		}
+		return b.String()
+	}
+	for i := 0; i < 3; i++ {
+		if got := render(ctx, E(`div`, nil, footer("en"))); got != `<div><footer>en</footer></div>` {
+			t.Fatalf("got %s", got)
+		}
+	}
+	if builds != 1 {
+		t.Errorf("builds = %d, want 1 for repeated renders", builds)
+	}
+	if got := render(ctx, footer("de")); got != `<footer>de</footer>` || builds != 2 {
+		t.Errorf("got %s after %d builds; a new key should render", got, builds)
+	}
+
+	// Formatted renders and a nil store bypass the cache.
+	var b strings.Builder
+	if _, err := RenderWithOptions(ctx, footer("en"), &b, &RenderOptions{Pretty: true}); err != nil || builds != 3 {
+		t.Errorf("pretty render: err %v, builds %d", err, builds)
+	}
+	render(WithCacheStore(ctx, nil), footer("en"))
+	if builds != 4 {
+		t.Errorf("builds = %d, nil store should not cache", builds)
+	}
+	never := Cached(CacheKey("Never"), -time.Minute, WithContext(func(ctx Context) Element {
+		builds++
+		return nil
+	}))
+	render(ctx, never)
+	render(ctx, never)
+	if _, ok := store.Get(CacheKey("Never")); ok || builds != 6 {
+		t.Errorf("builds = %d, a negative ttl should not cache", builds)
+	}
+
+	// Content with HeadItems is not cached, with or without an outlet: a partial render must
+	// not store a <title> that a later full page would replay in its body.
+	post := Cached(CacheKey("Post"), 0, WithContext(func(ctx Context) Element {
+		builds++
+		return E(`article`, nil, HeadItem(E(`title`, nil, Literal(`Post`))), Literal(`text`))
+	}))
+	if got := render(ctx, post); got != `<article><title>Post</title>text</article>` {
+		t.Errorf("partial: got %s", got)
+	}
+	page := E(`html`, nil, E(`head`, nil, HeadOutlet()), E(`body`, nil, E(`div`, nil, post)))
+	if got := render(ctx, page); got != `<html><head><title>Post</title></head><body><div><article>text</article></div></body></html>` {
+		t.Errorf("page after partial: got %s", got)
+	}
+	// Nor is content around a nested Cached with HeadItems.
+	outer := Cached(CacheKey("Outer"), 0, E(`section`, nil, post))
+	render(ctx, outer)
+	if _, ok := store.Get(CacheKey("Outer")); ok || builds != 9 {
+		t.Errorf("builds = %d, content with HeadItems should not be cached", builds)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	m := NewMemoryCache(10)
+	m.Set("a", []byte("1234"), 0) // 5 bytes
+	m.Set("b", []byte("1234"), 0)
+	m.Get("a")
+	m.Set("c", []byte("1234"), 0) // evicts b, the least recently used
+	if _, ok := m.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if _, ok := m.Get("a"); !ok {
+		t.Error("a should be kept")
+	}
+	m.Set("big", make([]byte, 20), 0)
+	if _, ok := m.Get("big"); ok || m.Len() != 2 {
+		t.Errorf("an entry over the bound should not be stored (len %d)", m.Len())
+	}
+	m.Set("d", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := m.Get("d"); ok {
+		t.Error("d should have expired")
+	}
+}
+
+func TestStatic(t *testing.T) {
+	builds := 0
+	el := E(`div`, nil, Static("<p>pre-rendered</p>", func() Element {
+		builds++
+		return E(`p`, nil, Literal(`built`))
+	}))
+	if got := renderString(t, el); got != `<div><p>pre-rendered</p></div>` || builds != 0 {
+		t.Errorf("plain render: got %s with %d builds", got, builds)
+	}
+	var b strings.Builder
+	if _, err := RenderWithOptions(context.Background(), el, &b, &RenderOptions{Pretty: true}); err != nil {
+		t.Fatalf("RenderWithOptions: %v", err)
+	}
+	if b.String() != "<div>\n  <p>built</p>\n</div>" || builds != 1 {
+		t.Errorf("formatted render should use build, got %q", b.String())
+	}
+}
+
+func TestWriteFunc(t *testing.T) {
+	tree := E(`main`, nil,
+		E(`p`, AttrList{{Key: `id`, Value: `intro`}, {Key: `class`, Value: []string{`a`, `b`}}}, Literal(`Hi `), R(`<you>`)),
+		E(`svg`, AttrList{{Key: `viewBox`, Value: `0 0 1 1`}}, E(`path`, AttrList{{Key: `d`, Value: `M0`}})),
+		E(`br`, nil),
+	)
+	written := WriteFunc(func(w *Writer) {
+		w.Open(`main`, true)
+		w.Body()
+		w.Open(`p`, true)
+		w.Attr(`id`, `intro`)
+		w.Attr(`class`, []string{`a`, `b`})
+		w.Body()
+		w.Text(`Hi `)
+		w.Value(`<you>`)
+		w.End()
+		w.Open(`svg`, true)
+		w.Attrs(AttrList{{Key: `viewBox`, Value: `0 0 1 1`}})
+		w.Body()
+		w.Open(`path`, false)
+		w.Attr(`d`, `M0`)
+		w.Body()
+		w.End()
+		w.End()
+		w.Open(`br`, false)
+		w.Body()
+		w.End()
+		w.End()
+	})
+	for _, opts := range []*RenderOptions{nil, {Minify: true}, {Pretty: true}} {
+		var want, got strings.Builder
+		if _, err := RenderWithOptions(context.Background(), tree, &want, opts); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RenderWithOptions(context.Background(), written, &got, opts); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("options %+v: got %q, want %q", opts, got.String(), want.String())
+		}
+	}
+	var b strings.Builder
+	if _, err := RenderFragment(written, "intro", &b); err != nil {
+		t.Fatalf("RenderFragment: %v", err)
+	}
+	if want := `<p id="intro" class="a b">Hi &lt;you&gt;</p>`; b.String() != want {
+		t.Errorf("fragment: got %s, want %s", b.String(), want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	page := E(`header`, nil,
+		E(`nav`, AttrList{{Key: `class`, Value: `top`}},
+			E(`a`, AttrList{{Key: `href`, Value: `/`}}, Literal(`Home`)),
+			E(`a`, AttrList{{Key: `class`, Value: `nav-cta primary`}, {Key: `href`, Value: `/start`}}, Literal("\n  Get  started\n")),
+		),
+		E(`button`, AttrList{{Key: `disabled`, Value: true}}, R(`<Save>`)),
+	)
+	link, err := Find(page, "nav a.nav-cta")
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if link.Tag != "a" || link.Attr("href") != "/start" || link.Text() != "Get started" {
+		t.Errorf("got <%s href=%q> %q", link.Tag, link.Attr("href"), link.Text())
+	}
+	if b, err := Find(page, "header > button[disabled]"); err != nil || !b.HasAttr("disabled") || b.Text() != "<Save>" {
+		t.Errorf("button: %v", err)
+	}
+	if all, err := FindAll(page, `nav > a, a[href^="/s"]`); err != nil || len(all) != 2 || all[0].Text() != "Home" {
+		t.Errorf("FindAll: %d nodes, %v", len(all), err)
+	}
+	if _, err := Find(page, "header > a"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+	if _, err := Find(page, "a:hover"); err == nil || !strings.Contains(err.Error(), "invalid selector") {
+		t.Errorf("expected invalid selector error, got %v", err)
+	}
+
+	// Table rows outside a table are kept as rendered.
+	root, err := Tree(R(E(`tr`, nil, E(`td`, nil, Literal(`1`))), E(`tr`, nil)))
+	if err != nil {
+		t.Fatalf("Tree: %v", err)
+	}
+	if len(root.Children) != 2 || root.Find("tr td").Text() != "1" || root.Find("tr > tr") != nil {
+		t.Errorf("got %s", root.HTML())
+	}
+}
+
+func TestHeadItem(t *testing.T) {
+	page := func(body ...Element) Element {
+		return E(`html`, nil,
+			E(`head`, nil,
+				E(`meta`, AttrList{{Key: `charset`, Value: `UTF-8`}}),
+				HeadOutlet(
+					E(`title`, nil, Literal(`Site`)),
+					E(`meta`, AttrList{{Key: `name`, Value: `description`}, {Key: `content`, Value: `default`}}),
+				),
+				E(`link`, AttrList{{Key: `rel`, Value: `stylesheet`}, {Key: `href`, Value: `/a.css`}}),
+			),
+			E(`body`, nil, body...),
+		)
+	}
+	article := E(`article`, nil,
+		HeadItem(E(`title`, nil, Literal(`Post`))),
+		HeadItem(
+			E(`meta`, AttrList{{Key: `name`, Value: `Description`}, {Key: `content`, Value: `A post`}}),
+			E(`link`, AttrList{{Key: `rel`, Value: `canonical`}, {Key: `href`, Value: `/post`}}),
+		),
+		E(`p`, nil, Literal(`Hello`)),
+	)
+
+	got := renderString(t, page(article, HeadItem(E(`title`, nil, Literal(`Post - Site`)))))
+	want := `<html><head><meta charset="UTF-8"><title>Post - Site</title><meta name="Description" content="A post">` +
+		`<link rel="canonical" href="/post"><link rel="stylesheet" href="/a.css"></head><body><article><p>Hello</p></article></body></html>`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if got := renderString(t, page()); got != `<html><head><meta charset="UTF-8"><title>Site</title><meta name="description" content="default">`+
+		`<link rel="stylesheet" href="/a.css"></head><body></body></html>` {
+		t.Errorf("defaults: got %s", got)
+	}
+
+	var b strings.Builder
+	if _, err := RenderWithOptions(context.Background(), page(article), &b, &RenderOptions{Pretty: true}); err != nil {
+		t.Fatalf("RenderWithOptions: %v", err)
+	}
+	if want := "<html>\n  <head>\n    <meta charset=\"UTF-8\">\n    <title>Post</title>\n    <meta name=\"Description\" content=\"A post\">\n" +
+		"    <link rel=\"canonical\" href=\"/post\">\n    <link rel=\"stylesheet\" href=\"/a.css\">\n  </head>\n" +
+		"  <body>\n    <article>\n      <p>Hello</p>\n    </article>\n  </body>\n</html>"; b.String() != want {
+		t.Errorf("pretty: got %q", b.String())
+	}
+
+	// Without an outlet, e.g. in a partial or a fragment, items render in place.
+	if got := renderString(t, article); got != `<article><title>Post</title><meta name="Description" content="A post"><link rel="canonical" href="/post"><p>Hello</p></article>` {
+		t.Errorf("no outlet: got %s", got)
+	}
+	b.Reset()
+	if _, err := RenderFragment(page(E(`div`, AttrList{{Key: `id`, Value: `x`}}, HeadItem(E(`title`, nil, Literal(`X`))))), "x", &b); err != nil {
+		t.Fatalf("RenderFragment: %v", err)
+	}
+	if want := `<div id="x"><title>X</title></div>`; b.String() != want {
+		t.Errorf("fragment: got %s, want %s", b.String(), want)
+	}
+}
+
+// writeSignal closes written on the first write that reaches it.
+type writeSignal struct {
+	strings.Builder
+	written chan struct{}
+}
+
+func (w *writeSignal) Write(p []byte) (int, error) {
+	if w.Len() == 0 {
+		close(w.written)
+	}
+	return w.Builder.Write(p)
+}
+
+func TestHeadOutlet_ReleasesLargePages(t *testing.T) {
+	w := &writeSignal{written: make(chan struct{})}
+	rows := strings.Repeat(`<p>row</p>`, headHoldSize/10)
+	page := E(`html`, nil,
+		E(`head`, nil, HeadOutlet(E(`title`, nil, Literal(`Site`)))),
+		E(`body`, nil,
+			HeadItem(E(`title`, nil, Literal(`Post`))),
+			Literal(rows),
+			WithContext(func(ctx Context) Element {
+				// The head and the page so far reach the writer before the render ends.
+				select {
+				case <-w.written:
+				case <-time.After(time.Second):
+					t.Error("nothing was written before the page finished")
+				}
+				return HeadItem(E(`title`, nil, Literal(`Late`)))
+			}),
+		),
+	)
+	n, err := RenderContext(context.Background(), page, w)
+	if err != nil {
+		t.Fatalf("RenderContext: %v", err)
+	}
+	// Items added after the head was written render in place.
+	want := `<html><head><title>Post</title></head><body>` + rows + `<title>Late</title></body></html>`
+	if w.String() != want || n != len(want) {
+		t.Errorf("got %d bytes %.80s..., want %d bytes %.80s...", n, w.String(), len(want), want)
+	}
+}
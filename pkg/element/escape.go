@@ -0,0 +1,17 @@
+package element
+
+import "strings"
+
+// Dynamic values are escaped while rendering by renderWriter.writeEscaped, which encodes
+// & < > " ' (safe for text and double-quoted attribute values) and writes non-breaking spaces
+// as &nbsp; so they survive copy/paste of the page source.
+
+// literalEscaper re-encodes template text at transpile time. The HTML parser decodes entities
+// (e.g. &lt; becomes <), so the literal must be encoded again to render as the author wrote it.
+// Quotes are left alone because the text is never placed inside an attribute.
+var literalEscaper = strings.NewReplacer(
+	`&`, "&amp;",
+	`<`, "&lt;",
+	`>`, "&gt;",
+	nbspChar, "&nbsp;",
+)
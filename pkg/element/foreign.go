@@ -0,0 +1,119 @@
+package element
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// svgElements and mathMLElements are the foreign-content tags the transpiler emits as plain
+// elements. Inside <svg> or <math> the parser marks every element with the namespace, so these
+// lists matter for components whose root is a foreign element (e.g. an icon component made
+// of <path>s), which the parser sees as unknown HTML tags.
+var svgElements = map[string]bool{
+	"svg": true, "a": true, "animate": true, "animatemotion": true, "animatetransform": true,
+	"circle": true, "clippath": true, "defs": true, "desc": true, "ellipse": true,
+	"feblend": true, "fecolormatrix": true, "fecomponenttransfer": true, "fecomposite": true,
+	"feconvolvematrix": true, "fediffuselighting": true, "fedisplacementmap": true,
+	"fedistantlight": true, "fedropshadow": true, "feflood": true, "fefunca": true,
+	"fefuncb": true, "fefuncg": true, "fefuncr": true, "fegaussianblur": true, "feimage": true,
+	"femerge": true, "femergenode": true, "femorphology": true, "feoffset": true,
+	"fepointlight": true, "fespecularlighting": true, "fespotlight": true, "fetile": true,
+	"feturbulence": true, "filter": true, "foreignobject": true, "g": true, "image": true,
+	"line": true, "lineargradient": true, "marker": true, "mask": true, "metadata": true,
+	"mpath": true, "path": true, "pattern": true, "polygon": true, "polyline": true,
+	"radialgradient": true, "rect": true, "set": true, "stop": true, "switch": true,
+	"symbol": true, "text": true, "textpath": true, "title": true, "tspan": true, "use": true,
+	"view": true,
+}
+
+var mathMLElements = map[string]bool{
+	"math": true, "annotation": true, "annotation-xml": true, "maction": true, "menclose": true,
+	"merror": true, "mfrac": true, "mi": true, "mmultiscripts": true, "mn": true, "mo": true,
+	"mover": true, "mpadded": true, "mphantom": true, "mprescripts": true, "mroot": true,
+	"mrow": true, "ms": true, "mspace": true, "msqrt": true, "mstyle": true, "msub": true,
+	"msubsup": true, "msup": true, "mtable": true, "mtd": true, "mtext": true, "mtr": true,
+	"munder": true, "munderover": true, "semantics": true,
+}
+
+// svgTagCase restores the camelCase SVG tag names the HTML parser lowercases outside <svg>.
+var svgTagCase = map[string]string{
+	"animatemotion": "animateMotion", "animatetransform": "animateTransform",
+	"clippath": "clipPath", "feblend": "feBlend", "fecolormatrix": "feColorMatrix",
+	"fecomponenttransfer": "feComponentTransfer", "fecomposite": "feComposite",
+	"feconvolvematrix": "feConvolveMatrix", "fediffuselighting": "feDiffuseLighting",
+	"fedisplacementmap": "feDisplacementMap", "fedistantlight": "feDistantLight",
+	"fedropshadow": "feDropShadow", "feflood": "feFlood", "fefunca": "feFuncA",
+	"fefuncb": "feFuncB", "fefuncg": "feFuncG", "fefuncr": "feFuncR",
+	"fegaussianblur": "feGaussianBlur", "feimage": "feImage", "femerge": "feMerge",
+	"femergenode": "feMergeNode", "femorphology": "feMorphology", "feoffset": "feOffset",
+	"fepointlight": "fePointLight", "fespecularlighting": "feSpecularLighting",
+	"fespotlight": "feSpotLight", "fetile": "feTile", "feturbulence": "feTurbulence",
+	"foreignobject": "foreignObject", "lineargradient": "linearGradient",
+	"radialgradient": "radialGradient", "textpath": "textPath",
+}
+
+// foreignAttrCase restores camelCase SVG and MathML attribute names (the HTML spec's
+// "adjust SVG attributes" and "adjust MathML attributes" tables).
+var foreignAttrCase = map[string]string{
+	"attributename": "attributeName", "attributetype": "attributeType",
+	"basefrequency": "baseFrequency", "baseprofile": "baseProfile", "calcmode": "calcMode",
+	"clippathunits": "clipPathUnits", "definitionurl": "definitionURL",
+	"diffuseconstant": "diffuseConstant", "edgemode": "edgeMode",
+	"filterunits": "filterUnits", "glyphref": "glyphRef",
+	"gradienttransform": "gradientTransform", "gradientunits": "gradientUnits",
+	"kernelmatrix": "kernelMatrix", "kernelunitlength": "kernelUnitLength",
+	"keypoints": "keyPoints", "keysplines": "keySplines", "keytimes": "keyTimes",
+	"lengthadjust": "lengthAdjust", "limitingconeangle": "limitingConeAngle",
+	"markerheight": "markerHeight", "markerunits": "markerUnits", "markerwidth": "markerWidth",
+	"maskcontentunits": "maskContentUnits", "maskunits": "maskUnits",
+	"numoctaves": "numOctaves", "pathlength": "pathLength",
+	"patterncontentunits": "patternContentUnits", "patterntransform": "patternTransform",
+	"patternunits": "patternUnits", "pointsatx": "pointsAtX", "pointsaty": "pointsAtY",
+	"pointsatz": "pointsAtZ", "preservealpha": "preserveAlpha",
+	"preserveaspectratio": "preserveAspectRatio", "primitiveunits": "primitiveUnits",
+	"refx": "refX", "refy": "refY", "repeatcount": "repeatCount", "repeatdur": "repeatDur",
+	"requiredextensions": "requiredExtensions", "requiredfeatures": "requiredFeatures",
+	"specularconstant": "specularConstant", "specularexponent": "specularExponent",
+	"spreadmethod": "spreadMethod", "startoffset": "startOffset",
+	"stddeviation": "stdDeviation", "stitchtiles": "stitchTiles",
+	"surfacescale": "surfaceScale", "systemlanguage": "systemLanguage",
+	"tablevalues": "tableValues", "targetx": "targetX", "targety": "targetY",
+	"textlength": "textLength", "viewbox": "viewBox", "viewtarget": "viewTarget",
+	"xchannelselector": "xChannelSelector", "ychannelselector": "yChannelSelector",
+	"zoomandpan": "zoomAndPan",
+}
+
+// isForeignTag reports whether name is an SVG or MathML tag that is not also an HTML tag.
+// Names shared with HTML (a, title, style) are foreign only inside <svg>, where the parser
+// sets the node's namespace.
+func isForeignTag(name string) bool {
+	name = strings.ToLower(name)
+	return !isStandard(name) && (svgElements[name] || mathMLElements[name])
+}
+
+// foreignTag returns the tag name to emit for a foreign element.
+func foreignTag(n *html.Node) string {
+	if t, ok := svgTagCase[strings.ToLower(n.Data)]; ok {
+		return t
+	}
+	return n.Data
+}
+
+// foreignAttrKey returns the attribute name to emit on a foreign element, restoring camelCase
+// and namespace prefixes such as xlink:href.
+func foreignAttrKey(a html.Attribute) string {
+	key := a.Key
+	if k, ok := foreignAttrCase[key]; ok {
+		key = k
+	}
+	if a.Namespace != "" {
+		return a.Namespace + ":" + key
+	}
+	return key
+}
+
+// isForeignRoot reports whether tag starts foreign content at render time.
+func isForeignRoot(tag string) bool {
+	return tag == "svg" || tag == "math"
+}
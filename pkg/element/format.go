@@ -0,0 +1,210 @@
+package element
+
+import (
+	"io"
+	"strings"
+)
+
+// RenderOptions selects an output format for RenderWithOptions. The zero value renders the
+// template's whitespace as written, like Render.
+type RenderOptions struct {
+	// Minify collapses whitespace: runs of spaces and newlines in text become one space, and
+	// whitespace next to block elements (div, p, li, ...) is dropped. A space between inline
+	// elements and text is kept, so "<b>a</b> <i>b</i>" still renders with its space.
+	Minify bool
+	// Pretty puts block elements on their own lines, indented by nesting depth. Whitespace is
+	// collapsed as with Minify; text and inline elements stay on their block's line. Use it for
+	// readable output in development and tests.
+	Pretty bool
+	// Indent is the indentation unit for Pretty. Defaults to two spaces.
+	Indent string
+	// Stream renders Await content concurrently and streams it after the rest of the page
+	// (see Await). The zero value renders Await content in place.
+	Stream StreamMode
+}
+
+// RenderWithOptions renders el to w under ctx with the output format in opts. Content of pre,
+// textarea, script and style is always written as is, as is Raw markup. A nil opts is the same
+// as RenderContext.
+func RenderWithOptions(ctx Context, el Element, w io.Writer, opts *RenderOptions) (int, error) {
+	if opts == nil || !opts.Minify && !opts.Pretty && opts.Stream == 0 {
+		return RenderContext(ctx, el, w)
+	}
+	rw := newRenderWriter(ctx, w)
+	rw.format = newFormatState(opts)
+	if opts.Stream != 0 {
+		rw.stream = &stream{opts: opts}
+	}
+	rw.renderTop(el)
+	if rw.stream != nil {
+		rw.streamAwaits()
+	}
+	return rw.finish()
+}
+
+// newFormatState returns the formatting state for opts, or nil when it formats nothing.
+func newFormatState(opts *RenderOptions) *formatState {
+	if !opts.Minify && !opts.Pretty {
+		return nil
+	}
+	f := &formatState{pretty: opts.Pretty, indent: opts.Indent}
+	if f.indent == "" {
+		f.indent = "  "
+	}
+	return f
+}
+
+// blockElements are the elements whose surrounding whitespace does not render. Others are
+// treated as inline, keeping one space around them.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "base": true, "blockquote": true,
+	"body": true, "caption": true, "col": true, "colgroup": true, "dd": true, "details": true,
+	"dialog": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "head": true, "header": true, "hgroup": true, "hr": true, "html": true,
+	"legend": true, "li": true, "link": true, "main": true, "menu": true, "meta": true,
+	"nav": true, "noscript": true, "ol": true, "optgroup": true, "option": true, "p": true,
+	"pre": true, "script": true, "search": true, "section": true, "source": true, "style": true,
+	"summary": true, "table": true, "tbody": true, "td": true, "template": true, "tfoot": true,
+	"th": true, "thead": true, "title": true, "tr": true, "track": true, "ul": true,
+}
+
+// preserveElements keep their content byte for byte.
+var preserveElements = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+}
+
+// formatState is the renderWriter state for RenderWithOptions.
+type formatState struct {
+	pretty bool
+	indent string
+
+	// space is a collapsed whitespace run not yet written; it is dropped at block boundaries.
+	space bool
+	// edge is set right after a block element's start or end tag, where whitespace is dropped.
+	edge bool
+	// newline asks for a line break before the next content (pretty, after a block end tag).
+	newline bool
+	// preserve counts open preserveElements; formatting is off while it is positive.
+	preserve int
+	// blocks has one entry per open block element (pretty): whether it has block children,
+	// which puts its end tag on its own line.
+	blocks []bool
+}
+
+// openTag is called before an element's start tag. hasEnd reports whether closeTag follows.
+func (rw *renderWriter) openTag(tag string, hasEnd bool) {
+	f := rw.format
+	if f.preserve > 0 {
+		return
+	}
+	if !blockElements[tag] {
+		rw.beforeContent()
+		return
+	}
+	f.space, f.newline, f.edge = false, false, true
+	if !f.pretty {
+		return
+	}
+	if rw.n > 0 {
+		rw.lineBreak(len(f.blocks))
+	}
+	if n := len(f.blocks); n > 0 {
+		f.blocks[n-1] = true
+	}
+	if hasEnd {
+		f.blocks = append(f.blocks, false)
+	} else {
+		f.newline = true
+	}
+}
+
+// startContent is called after an element's start tag, before its children.
+func (rw *renderWriter) startContent(tag string) {
+	if preserveElements[tag] {
+		rw.format.preserve++
+	}
+}
+
+// closeTag is called before an element's end tag.
+func (rw *renderWriter) closeTag(tag string) {
+	f := rw.format
+	preserved := preserveElements[tag]
+	if preserved {
+		f.preserve--
+	}
+	if f.preserve > 0 || !blockElements[tag] {
+		return
+	}
+	f.space, f.edge = false, true
+	if !f.pretty {
+		return
+	}
+	n := len(f.blocks)
+	hasBlocks := f.blocks[n-1]
+	f.blocks = f.blocks[:n-1]
+	if hasBlocks && !preserved {
+		rw.lineBreak(n - 1)
+	}
+	f.newline = true
+}
+
+// beforeContent writes the pending line break or space before text or an inline element.
+func (rw *renderWriter) beforeContent() {
+	f := rw.format
+	if f.newline {
+		f.newline, f.space, f.edge = false, false, false
+		rw.lineBreak(len(f.blocks))
+		return
+	}
+	if f.space && !f.edge && rw.n > 0 {
+		rw.write(" ")
+	}
+	f.space, f.edge = false, false
+}
+
+func (rw *renderWriter) lineBreak(depth int) {
+	rw.write("\n")
+	for i := 0; i < depth; i++ {
+		rw.write(rw.format.indent)
+	}
+}
+
+// formatText writes text content with whitespace collapsed, escaping it when escape is set.
+func (rw *renderWriter) formatText(s string, escape bool) {
+	if rw.format.preserve > 0 {
+		rw.writeText(s, escape)
+		return
+	}
+	for len(s) > 0 {
+		i := strings.IndexFunc(s, isHTMLSpace)
+		if i != 0 {
+			if i < 0 {
+				i = len(s)
+			}
+			rw.beforeContent()
+			rw.writeText(s[:i], escape)
+			s = s[i:]
+			continue
+		}
+		j := strings.IndexFunc(s, func(r rune) bool { return !isHTMLSpace(r) })
+		if j < 0 {
+			j = len(s)
+		}
+		rw.format.space = true
+		s = s[j:]
+	}
+}
+
+func (rw *renderWriter) writeText(s string, escape bool) {
+	if escape {
+		rw.writeEscapedSet(s, false)
+	} else {
+		rw.write(s)
+	}
+}
+
+// isHTMLSpace reports ASCII whitespace as defined by HTML; other spaces such as U+00A0 are text.
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
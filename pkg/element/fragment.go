@@ -0,0 +1,115 @@
+package element
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrFragmentNotFound is returned by RenderFragment when no element matches the fragment name.
+var ErrFragmentNotFound = errors.New("element: fragment not found")
+
+// errFragmentRendered stops rendering once the fragment has been written; finish reports it
+// as success.
+var errFragmentRendered = errors.New("element: fragment rendered")
+
+// RenderFragment renders only the first element in el whose id attribute equals name, or that
+// is marked with Fragment(name, ...) (fragment="name" in templates), including the element's
+// own tag. Use it to serve an HTMX partial from the same component as the full page:
+//
+//	element.RenderFragment(HomeComp(props, nil), "time-result", w)
+//
+// The tree is walked as for a full render, so components run and build their content, but
+// nothing is written outside the fragment and rendering stops after it. Elements that are not
+// built by this package are skipped. Returns ErrFragmentNotFound when nothing matches.
+func RenderFragment(el Element, name string, w io.Writer) (int, error) {
+	return RenderFragmentContext(context.Background(), el, name, w)
+}
+
+// RenderFragmentContext is RenderFragment under ctx, as RenderContext is to Render.
+func RenderFragmentContext(ctx context.Context, el Element, name string, w io.Writer) (int, error) {
+	rw := newRenderWriter(ctx, w)
+	rw.fragment = name
+	rw.renderTop(el)
+	if rw.err == nil {
+		rw.err = ErrFragmentNotFound
+	}
+	if rw.err == errFragmentRendered {
+		rw.err = nil
+	}
+	return rw.finish()
+}
+
+// Fragment marks el as the fragment name for RenderFragment; in a normal render it renders el
+// as is. The transpiler emits it for fragment="name" on an element or component.
+func Fragment(name string, el Element) Element {
+	return fragmentElement{name: name, el: el}
+}
+
+type fragmentElement struct {
+	name string
+	el   Element
+}
+
+func (f fragmentElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, f)
+}
+
+func (f fragmentElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, f)
+}
+
+func (f fragmentElement) renderTo(rw *renderWriter) {
+	if rw.searching() && f.name == rw.fragment {
+		rw.renderFragment(f.el)
+		return
+	}
+	renderChild(rw, f.el)
+}
+
+// searching reports whether rw is looking for a fragment: nothing is written until it is found.
+func (rw *renderWriter) searching() bool {
+	return rw.fragment != "" && !rw.inFragment
+}
+
+// renderFragment renders el as the found fragment and stops the render.
+func (rw *renderWriter) renderFragment(el Element) {
+	rw.inFragment = true
+	renderChild(rw, el)
+	rw.inFragment = false
+	rw.fail(errFragmentRendered)
+}
+
+// matchesFragment reports whether e's id attribute equals the fragment being searched for.
+func (e element) matchesFragment(rw *renderWriter) bool {
+	for _, a := range e.attrs {
+		if a.Key != "id" {
+			continue
+		}
+		// Dynamic ids (e.g. "row-{item.ID}") are rendered to compare them.
+		v, ok := attrString(rw.ctx, a.Value)
+		return ok && v == rw.fragment
+	}
+	return false
+}
+
+// attrString returns an attribute value as it is written, or false when it is not written or
+// fails to render.
+func attrString(ctx context.Context, v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case Literal:
+		return string(v), true
+	case nil:
+		return "", false
+	}
+	var b strings.Builder
+	sub := newRenderWriter(ctx, &b)
+	renderAttrValue(sub, v)
+	if _, err := sub.finish(); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
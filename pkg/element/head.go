@@ -0,0 +1,220 @@
+package element
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+)
+
+// HeadItem adds items, typically <title>, <meta> and <link> elements, to the page's head: when
+// a HeadOutlet rendered earlier in the same render, the items are written at the outlet
+// instead of in place. This lets a nested component set the title or description of the page
+// its layout renders. Templates use <head-item>, and a <title> outside <head> is hoisted the
+// same way:
+//
+//	<head-item><meta name="description" content={props.Summary}></head-item>
+//
+// Items with the same key replace earlier ones in place, so the last component to set the
+// title wins: <title> and <base> are keyed by tag, <meta> by charset, name, property,
+// http-equiv or itemprop, <link rel="canonical"> by rel and other links by rel and href,
+// <script> by src. Other items are written as they come.
+//
+// Without an outlet (e.g. an HTMX partial, or a RenderFragment render) items render in place.
+// Cached content that renders items, with or without an outlet, is not cached. Items rendered
+// after the head was written (see HeadOutlet) render in place.
+func HeadItem(items ...Element) Element {
+	return headItemElement{items: items}
+}
+
+// HeadOutlet marks where a layout writes the HeadItem items of its render, after defaults: the
+// layout's own title, description and so on, which items with the same key replace. Templates
+// use <head-outlet/> in <head>; the <title>, <meta name|property|http-equiv> and
+// <link rel="canonical"> elements of that head become its defaults.
+//
+// Output after the outlet is held in memory, since a later component may still add items. The
+// head and the held output are written when the render ends, when streamed Await content
+// starts, or once 32 KB is held, so a large page still reaches the client as it renders;
+// components should add their items before that much of the page.
+func HeadOutlet(defaults ...Element) Element {
+	return headOutletElement{defaults: defaults}
+}
+
+type headItemElement struct {
+	items []Element
+}
+
+func (h headItemElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, h)
+}
+
+func (h headItemElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, h)
+}
+
+func (h headItemElement) renderTo(rw *renderWriter) {
+	rw.headItems++
+	head := rw.head
+	if head == nil || head.resolved || rw.inAttr || rw.foreign || rw.searching() {
+		for _, el := range h.items {
+			renderChild(rw, el)
+		}
+		return
+	}
+	for _, el := range h.items {
+		head.add(rw.ctx, el)
+	}
+}
+
+type headOutletElement struct {
+	defaults []Element
+}
+
+func (h headOutletElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, h)
+}
+
+func (h headOutletElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, h)
+}
+
+func (h headOutletElement) renderTo(rw *renderWriter) {
+	if rw.head != nil || rw.fragment != "" || rw.inAttr || rw.foreign {
+		// Only the first outlet of a page render collects items; elsewhere the defaults
+		// render in place.
+		for _, el := range h.defaults {
+			renderChild(rw, el)
+		}
+		return
+	}
+	head := &headState{owner: rw, w: rw.w, buf: rw.buf}
+	if rw.format != nil {
+		f := *rw.format
+		f.blocks = slices.Clone(f.blocks)
+		head.format = &f
+	}
+	for _, el := range h.defaults {
+		head.add(rw.ctx, el)
+	}
+	rw.head = head
+	rw.w, rw.buf = &head.rest, nil
+}
+
+// headState collects the HeadItem items of a render after its HeadOutlet.
+type headState struct {
+	items []Element
+	keys  map[string]int // index in items of each keyed item
+	// resolved is set once the items have been written; later items render in place.
+	resolved bool
+
+	// owner is the renderWriter the outlet rendered in; Cached shares the state with the
+	// renderWriter of a miss. Its writer, buffer and formatting at the outlet are kept while
+	// output after the outlet goes to rest.
+	owner  *renderWriter
+	w      io.Writer
+	buf    *bufio.Writer
+	format *formatState
+	rest   bytes.Buffer
+}
+
+// add adds el, replacing the item with the same key.
+func (h *headState) add(ctx context.Context, el Element) {
+	key := headKey(ctx, el)
+	if key == "" {
+		h.items = append(h.items, el)
+		return
+	}
+	if i, ok := h.keys[key]; ok {
+		h.items[i] = el
+		return
+	}
+	if h.keys == nil {
+		h.keys = make(map[string]int)
+	}
+	h.keys[key] = len(h.items)
+	h.items = append(h.items, el)
+}
+
+// headHoldSize bounds the output held after a HeadOutlet.
+const headHoldSize = 32 << 10
+
+// releaseHead resolves the head once headHoldSize of output is held after the outlet. It runs
+// between elements, where no start tag or attribute value is open.
+func (rw *renderWriter) releaseHead() {
+	if rw.head.rest.Len() >= headHoldSize && !rw.inTag && !rw.inAttr && !rw.foreign {
+		rw.resolveHead()
+	}
+}
+
+// resolveHead writes the head items at the outlet, followed by the output held since. It runs
+// when the render ends, before streamed Await content is flushed, and from releaseHead.
+func (rw *renderWriter) resolveHead() {
+	h := rw.head
+	if h == nil || h.resolved || h.owner != rw {
+		return
+	}
+	h.resolved = true
+	rw.w, rw.buf = h.w, h.buf
+	format := rw.format
+	rw.format = h.format
+	for _, el := range h.items {
+		renderChild(rw, el)
+	}
+	rw.format = format
+	// The held output was counted when it was written.
+	rw.n -= h.rest.Len()
+	_, _ = rw.Write(h.rest.Bytes())
+	h.rest = bytes.Buffer{}
+}
+
+// headKey returns the key that deduplicates a head item, or "" when items like it are kept.
+func headKey(ctx context.Context, el Element) string {
+	var e element
+	switch v := el.(type) {
+	case element:
+		e = v
+	case staticElement:
+		if b, ok := v.build().(element); ok {
+			e = b
+		}
+	}
+	attr := func(key string) string {
+		for _, a := range e.attrs {
+			if a.Key == key {
+				s, _ := attrString(ctx, a.Value)
+				return s
+			}
+		}
+		return ""
+	}
+	switch e.tag {
+	case "title", "base":
+		return e.tag
+	case "meta":
+		for _, a := range e.attrs {
+			if a.Key == "charset" {
+				return "meta charset"
+			}
+		}
+		for _, key := range []string{"name", "property", "http-equiv", "itemprop"} {
+			if v := attr(key); v != "" {
+				return "meta " + key + "=" + strings.ToLower(v)
+			}
+		}
+	case "link":
+		rel := strings.ToLower(attr("rel"))
+		if rel == "canonical" {
+			return "link canonical"
+		}
+		if href := attr("href"); rel != "" && href != "" {
+			return "link " + rel + " " + href
+		}
+	case "script":
+		if src := attr("src"); src != "" {
+			return "script " + src
+		}
+	}
+	return ""
+}
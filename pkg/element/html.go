@@ -2,6 +2,7 @@ package element
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -22,47 +23,37 @@ type CompInfo struct {
 // Html is a parsed HTML template that can be rendered to Go code. Created by NewHtml.
 type Html interface {
 	RenderGolangCode(comps map[string]CompInfo) (string, error)
+	// RenderWriterCode is RenderGolangCode for the writer backend (see Writer).
+	RenderWriterCode(comps map[string]CompInfo) (string, error)
+}
+
+// SourceError is a template error at a line of the HTML passed to NewHtml (line 1 is its first line).
+// The transpiler maps Line to the .html file when reporting a TranspileError.
+type SourceError struct {
+	Line    int
+	Message string
+
+	// tag, when set and Line is 0, is located in the source to fill in Line.
+	tag string
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
 }
 
 type htmlc struct {
 	nodes []*html.Node
+	src   []byte
 }
 
 func processFor(n *html.Node, comps map[string]CompInfo) (string, error) {
 	var buffer strings.Builder
 
-	key := ""
-	as := ""
-	for _, a := range n.Attr {
-		if a.Key == "items" {
-			key = a.Val
-		}
-		if a.Key == "as" {
-			as = a.Val
-		}
-
-		if key != "" && as != "" {
-			break
-		}
-	}
-
-	if as == "" {
-		as = "item"
-	}
-
-	if key == "" {
-		return "", fmt.Errorf("key items not found in 'for' element")
-
-	}
-	if strings.HasPrefix(key, "{$attrs.") {
-		return "", fmt.Errorf("cannot use $attrs in 'for' element, please use props instead")
-	}
-	if !strings.HasPrefix(key, "{") || !strings.HasSuffix(key, "}") {
-		return "", fmt.Errorf("invalid key %s in 'for' element", key)
+	key, as, err := forParams(n)
+	if err != nil {
+		return "", err
 	}
 
-	key = processRaws(key)
-
 	// key = strings.Trim(key, "{}")
 
 	buffer.WriteString("R(func() []Element {\n")
@@ -88,6 +79,39 @@ func processFor(n *html.Node, comps map[string]CompInfo) (string, error) {
 	return buffer.String(), nil
 }
 
+// forParams returns the items expression and loop variable of a <for> element.
+func forParams(n *html.Node) (key, as string, err error) {
+	for _, a := range n.Attr {
+		if a.Key == "items" {
+			key = a.Val
+		}
+		if a.Key == "as" {
+			as = a.Val
+		}
+
+		if key != "" && as != "" {
+			break
+		}
+	}
+
+	if as == "" {
+		as = "item"
+	}
+
+	if key == "" {
+		return "", "", fmt.Errorf("key items not found in 'for' element")
+
+	}
+	if strings.HasPrefix(key, "{$attrs.") {
+		return "", "", fmt.Errorf("cannot use $attrs in 'for' element, please use props instead")
+	}
+	if !strings.HasPrefix(key, "{") || !strings.HasSuffix(key, "}") {
+		return "", "", fmt.Errorf("invalid key %s in 'for' element", key)
+	}
+
+	return processRaws(key), as, nil
+}
+
 // processIfChain handles <if condition={expr}>...</if> and optional <elseif condition={}>...</elseif>, <else>...</else>.
 // Returns generated code and the last node consumed (so caller can skip to last.NextSibling).
 func processIfChain(ifNode *html.Node, comps map[string]CompInfo) (string, *html.Node, error) {
@@ -141,6 +165,39 @@ done:
 	return fmt.Sprintf("R(func() []Element {\n%s\n}(),)", inner), last, nil
 }
 
+// processAwait handles <await>...</await> with an optional <fallback>...</fallback> child. The
+// content is built inside a function so its expressions run when the Await resolves, not
+// when the enclosing component is built.
+func processAwait(n *html.Node, comps map[string]CompInfo) (string, error) {
+	var fallback *html.Node
+	var parts []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "fallback" {
+			if fallback != nil {
+				return "", fmt.Errorf("'await' element has more than one 'fallback'")
+			}
+			fallback = c
+			continue
+		}
+		b, err := render(c, comps)
+		if err != nil {
+			return "", err
+		}
+		if len(b) > 0 {
+			parts = append(parts, b)
+		}
+	}
+	fallbackCode := "nil"
+	if fallback != nil {
+		code, err := renderChildren(fallback, comps)
+		if err != nil {
+			return "", err
+		}
+		fallbackCode = fmt.Sprintf("R(%s)", code)
+	}
+	return fmt.Sprintf("Await(%s, func(ctx Context) Element {\nreturn R(%s)\n})", fallbackCode, strings.Join(parts, ",")), nil
+}
+
 func getConditionAttr(n *html.Node) (string, error) {
 	for _, a := range n.Attr {
 		if a.Key == "condition" {
@@ -177,33 +234,93 @@ func collectChildNodes(n *html.Node) []*html.Node {
 	return out
 }
 
+// attrValueCode returns the Go code for the value of an element attribute.
+func attrValueCode(a html.Attribute) string {
+	if a.Val == "" {
+		// Valueless attribute (e.g. disabled, crossorigin): render bare.
+		return "true"
+	}
+	return processRaws(a.Val)
+}
+
+// elementKind reports whether n renders as an element (HTML, SVG/MathML, or a custom element)
+// rather than a component, with the tag to emit. Unknown tags are a SourceError.
+func elementKind(n *html.Node, comps map[string]CompInfo) (tag string, foreign, isElement bool, err error) {
+	isStd := isStandard(n.Data)
+	// SVG and MathML elements: anything the parser put in the svg/math namespace, and
+	// foreign-only tags outside it unless a component has that name.
+	_, isComp := comps[n.Data]
+	foreign = n.Namespace == "svg" || n.Namespace == "math" || !isStd && !isComp && isForeignTag(n.Data)
+	// Unknown hyphenated tags are custom elements (web components) and render as they are.
+	custom := !isStd && !foreign && !isComp && isCustomElement(n.Data)
+	if !isStd && !foreign && !isComp && !custom {
+		return "", false, false, &SourceError{
+			Message: fmt.Sprintf("unknown element <%s>: not an HTML element or a defined component (custom elements need a hyphen, e.g. <x-%s>)", n.Data, n.Data),
+			tag:     n.Data,
+		}
+	}
+	if isComp && !isStd && !foreign {
+		return "", false, false, nil
+	}
+	tag = strings.TrimSpace(n.Data)
+	if foreign {
+		tag = foreignTag(n)
+	}
+	return tag, foreign, true, nil
+}
+
+// attrListCode returns the AttrList code for the attributes of element n, and whether n spreads
+// the component's caller attributes.
+func attrListCode(n *html.Node, foreign bool) (code string, spread bool, err error) {
+	var buffer strings.Builder
+	// AttrList keeps the template's attribute order in the rendered HTML.
+	buffer.WriteString("AttrList{")
+	for _, a := range n.Attr {
+		if isSpread(a.Key) {
+			if a.Key != spreadAttrs {
+				return "", false, fmt.Errorf("<%s>: unsupported spread %s (only %s is supported)", n.Data, a.Key, spreadAttrs)
+			}
+			spread = true
+			continue
+		}
+		key := a.Key
+		if foreign {
+			key = foreignAttrKey(a)
+		}
+		buffer.WriteString(fmt.Sprintf("{Key:`%s`,Value:%s},", key, attrValueCode(a)))
+	}
+	buffer.WriteString("}")
+	return buffer.String(), spread, nil
+}
+
 // generateProps returns (code, complete, err). When complete is true, code is the full component call
 // (including slot props and default children). When false, caller must append children and ")".
 func generateProps(n *html.Node, comps map[string]CompInfo, children []*html.Node) (string, bool, error) {
-	isStd := isStandard(n.Data)
+	tag, foreign, isElement, err := elementKind(n, comps)
+	if err != nil {
+		return "", false, err
+	}
 
 	var buffer strings.Builder
 
-	if isStd {
+	if isElement {
 		buffer.WriteString("E(`")
-		buffer.WriteString(strings.TrimSpace(n.Data))
+		buffer.WriteString(tag)
 		buffer.WriteString("`,")
-		buffer.WriteString("Attrs{")
-
-		for _, a := range n.Attr {
-			if isStd {
-				buffer.WriteString(fmt.Sprintf("`%s`:", a.Key))
-			} else {
-				if prop, ok := comps[n.Data].Props[a.Key]; ok {
-					buffer.WriteString(fmt.Sprintf("%s:", utils.Capitalize(prop)))
-				} else {
-					buffer.WriteString(fmt.Sprintf("%s:", a.Key))
-				}
-			}
-			buffer.WriteString(processRaws(a.Val))
-			buffer.WriteString(",")
+		if len(n.Attr) == 0 {
+			buffer.WriteString("nil,")
+			return buffer.String(), false, nil
+		}
+		list, spread, err := attrListCode(n, foreign)
+		if err != nil {
+			return "", false, err
+		}
+		if spread {
+			// Spread merges the component's caller attributes over the template's.
+			list = fmt.Sprintf("Spread(%s,attrs)", list)
 		}
-		buffer.WriteString("},")
+		buffer.WriteString(list)
+		buffer.WriteString(",")
 
 		return buffer.String(), false, nil
 	}
@@ -211,6 +328,9 @@ func generateProps(n *html.Node, comps map[string]CompInfo, children []*html.Nod
 	var props strings.Builder
 	var attrs strings.Builder
 	for _, a := range n.Attr {
+		if isSpread(a.Key) {
+			return "", false, fmt.Errorf("<%s>: %s is only supported on HTML elements", n.Data, a.Key)
+		}
 		if prop, ok := comps[n.Data].Props[a.Key]; ok {
 			props.WriteString(fmt.Sprintf("%s:", utils.Capitalize(prop)))
 
@@ -302,13 +422,18 @@ func (h htmlc) RenderGolangCode(comps map[string]CompInfo) (string, error) {
 
 	buffer.WriteString("R(")
 	for _, n := range h.nodes {
+		if n.Type == html.ElementNode && n.Data == "html" {
+			// The parser drops the doctype; document components always get the HTML5 one so
+			// browsers render in standards mode.
+			bts = append(bts, "Literal(`<!DOCTYPE html>`)")
+		}
 		b, err := render(n, comps)
 		if err != nil {
-			return "", err
+			return "", h.locate(err)
+		}
+		if len(b) > 0 {
+			bts = append(bts, b)
 		}
-
-		bts = append(bts, b)
-		// buffer.Write(b)
 	}
 
 	buffer.WriteString(strings.Join(bts, ","))
@@ -317,9 +442,22 @@ func (h htmlc) RenderGolangCode(comps map[string]CompInfo) (string, error) {
 	return buffer.String(), nil
 }
 
+// locate fills in the line of a SourceError that names its tag.
+func (h htmlc) locate(err error) error {
+	var se *SourceError
+	if errors.As(err, &se) && se.Line == 0 && se.tag != "" {
+		se.Line = lineOfTag(h.src, se.tag)
+	}
+	return err
+}
+
 // NewHtml parses htmlCode (a fragment or full document) and returns an Html that can generate Go code via RenderGolangCode.
 // Used by the transpiler for each component's "html" section.
 func NewHtml(htmlCode []byte) (Html, error) {
+	if err := checkVoidChildren(htmlCode); err != nil {
+		return nil, err
+	}
+
 	context := &html.Node{
 		Type:     html.ElementNode,
 		DataAtom: atom.Div,
@@ -330,17 +468,111 @@ func NewHtml(htmlCode []byte) (Html, error) {
 		context = nil
 	}
 
-	n, err := html.ParseFragment(bytes.NewReader(bytes.Trim(bytes.TrimSpace(htmlCode), "\n")), context)
+	// <head-outlet/> becomes a <meta> marker so it stays in <head>.
+	src := reHeadOutlet.ReplaceAll(htmlCode, []byte("<meta "+headOutletAttr+">"))
+
+	n, err := html.ParseFragment(bytes.NewReader(bytes.Trim(bytes.TrimSpace(src), "\n")), context)
 	if err != nil {
 		return nil, err
 	}
 
 	return htmlc{
 		nodes: n,
+		src:   htmlCode,
 	}, nil
 }
 
+// lineOfTag returns the line of the first <tag in src (case-insensitive), or 0 if not found.
+func lineOfTag(src []byte, tag string) int {
+	lower := bytes.ToLower(src)
+	needle := []byte("<" + strings.ToLower(tag))
+	for off := 0; ; {
+		i := bytes.Index(lower[off:], needle)
+		if i < 0 {
+			return 0
+		}
+		end := off + i + len(needle)
+		if end == len(lower) || !isTagNameByte(lower[end]) {
+			return 1 + bytes.Count(src[:off+i], []byte("\n"))
+		}
+		off = end
+	}
+}
+
+func isTagNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == ':' || c == '.'
+}
+
+// checkVoidChildren reports a void element (e.g. <input>) written with content and an end tag.
+// The HTML parser silently moves such content out of the element, so it is caught on the raw tokens.
+func checkVoidChildren(htmlCode []byte) error {
+	z := html.NewTokenizer(bytes.NewReader(htmlCode))
+	line := 1
+	depth := 0
+	pending, pendingLine, pendingDepth, hasContent := "", 0, 0, false
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return nil
+		}
+		startLine := line
+		line += bytes.Count(z.Raw(), []byte("\n"))
+		switch tt {
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if pending != "" {
+				hasContent = true
+			}
+			if IsVoid(tag) {
+				if pending == "" {
+					pending, pendingLine, pendingDepth, hasContent = tag, startLine, depth, false
+				}
+				continue
+			}
+			depth++
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if pending != "" && tag == pending && depth == pendingDepth {
+				if hasContent {
+					return &SourceError{Line: pendingLine, Message: fmt.Sprintf("void element <%s> cannot have children", tag)}
+				}
+				pending = ""
+				continue
+			}
+			if IsVoid(tag) {
+				continue
+			}
+			depth--
+			if pending != "" && depth < pendingDepth {
+				pending = ""
+			}
+		case html.TextToken:
+			if pending != "" && len(bytes.TrimSpace(z.Raw())) > 0 {
+				hasContent = true
+			}
+		}
+	}
+}
+
 func processNode(input string) string {
+	tokens := textTokens(input)
+	if len(tokens) == 0 {
+		return ""
+	}
+	// Plain template text is a Literal, which is an Element on its own.
+	if len(tokens) == 1 && reLiteralToken.MatchString(tokens[0]) {
+		return tokens[0]
+	}
+	// Join tokens to form the final R(...) string
+	result := fmt.Sprintf("R(%s)", strings.Join(tokens, ", "))
+	return result
+}
+
+// textTokens returns the code for each part of a text node: Literal(`...`) for template text,
+// Go expressions for {expr}.
+func textTokens(input string) []string {
 	// Regular expression to match {item} or {{item}}
 	varPattern := regexp.MustCompile(`\{{2,}(.*)\}{2,}`)
 	tokens := []string{}
@@ -351,30 +583,93 @@ func processNode(input string) string {
 
 	// Iterate over the split parts and matches to construct the result
 	for i, part := range splitParts {
-		tokens = append(tokens, processRaws(part))
+		if part != "" {
+			tokens = append(tokens, exprTokens(part, textLiteral, false)...)
+		}
 
 		if i < len(matches) {
 			match := matches[i]
-			tokens = append(tokens, fmt.Sprintf("`%s`", match[0]))
+			tokens = append(tokens, textLiteral(match[0]))
 		}
 	}
+	return tokens
+}
 
-	inners := strings.Join(tokens, ", ")
-	if len(inners) == 0 {
-		return ""
+// reLiteralToken matches a single Literal(`...`) token produced by textLiteral.
+var reLiteralToken = regexp.MustCompile("^Literal\\(`[^`]*`\\)$")
+
+// quoteLiteral emits s as a Go raw string; used for attribute values and other non-text positions.
+func quoteLiteral(s string) string {
+	return fmt.Sprintf("`%s`", s)
+}
+
+// textLiteral emits template text as a Literal so the runtime writes it without escaping.
+// The parser has decoded entities in s, so it is re-encoded here.
+func textLiteral(s string) string {
+	return fmt.Sprintf("Literal(`%s`)", literalEscaper.Replace(s))
+}
+
+// keptCommentPrefix marks a template comment that is kept in the rendered HTML.
+const keptCommentPrefix = "!"
+
+// keptComment reports whether a comment with the given data is rendered, and its text.
+func keptComment(data string) (string, bool) {
+	if strings.HasPrefix(data, keptCommentPrefix) {
+		return strings.TrimPrefix(data, keptCommentPrefix), true
 	}
-	// Join tokens to form the final R(...) string
-	result := fmt.Sprintf("R(%s)", strings.Join(tokens, ", "))
-	return result
+	if strings.HasPrefix(data, "[if ") {
+		return data, true
+	}
+	return "", false
+}
+
+// spreadAttrs is the attribute that spreads a component's caller attributes onto an element:
+// <div class="card" {...attrs}>. The parser lowercases attribute names, so the spread names the
+// attrs parameter of the generated component function rather than props.Attrs.
+const spreadAttrs = "{...attrs}"
+
+func isSpread(key string) bool {
+	return strings.HasPrefix(key, "{...")
 }
 
 // matchOneBraced matches a single {expr} (no nested braces) so multiple {a} {b} in one string work.
 var reOneBraced = regexp.MustCompile(`\{([^{}]*)\}`)
 
 func processRaws(input string) string {
+	return processExprs(input, quoteLiteral, false)
+}
+
+// processText is processRaws for text content: literal parts become Literal values, expressions stay escaped.
+func processText(input string) string {
+	return processExprs(input, textLiteral, false)
+}
+
+// processRawText is processText for the content of <raw>: every expression is emitted as Raw.
+func processRawText(input string) string {
+	return processExprs(input, textLiteral, true)
+}
+
+// rawPrefix marks an expression whose value is trusted markup: {@html props.Body}.
+const rawPrefix = "@html "
+
+// processExprs splits input into literal parts and {expr} parts; literal formats the literal parts.
+// When raw is true, or an expression starts with "@html ", the expression is wrapped in Raw(...).
+func processExprs(input string, literal func(string) string, raw bool) string {
+	tokens := exprTokens(input, literal, raw)
+	if len(tokens) > 1 {
+		return fmt.Sprintf("R(%s)", strings.Join(tokens, ","))
+	}
+	if len(tokens) == 1 {
+		return tokens[0]
+	}
+	return literal(input)
+}
+
+// exprTokens returns the Go code for each literal part and {expr} part of input, in order.
+func exprTokens(input string, literal func(string) string, raw bool) []string {
 	matches := reOneBraced.FindAllStringSubmatchIndex(input, -1)
 	if len(matches) == 0 {
-		return fmt.Sprintf("`%s`", input)
+		return []string{literal(input)}
 	}
 
 	var tokens []string
@@ -382,39 +677,67 @@ func processRaws(input string) string {
 	for _, m := range matches {
 		// m[0], m[1] = full match; m[2], m[3] = capture group (content inside {})
 		if lit := input[lastEnd:m[0]]; lit != "" {
-			tokens = append(tokens, fmt.Sprintf("`%s`", lit))
+			tokens = append(tokens, literal(lit))
 		}
 		val := input[m[2]:m[3]]
+		wrapRaw := raw
+		if strings.HasPrefix(val, rawPrefix) {
+			val = strings.TrimSpace(val[len(rawPrefix):])
+			wrapRaw = true
+		}
 		if val != "" {
 			if strings.HasPrefix(val, "$") {
 				f := strings.Split(val, ".")
 				if len(f) >= 2 {
-					tokens = append(tokens, fmt.Sprintf("%s[\"%s\"]", strings.Replace(f[0], "$", "", 1), f[1]))
+					tokens = append(tokens, rawExpr(fmt.Sprintf("%s[\"%s\"]", strings.Replace(f[0], "$", "", 1), f[1]), wrapRaw))
 				} else {
-					tokens = append(tokens, fmt.Sprintf("`%s`", val))
+					tokens = append(tokens, literal(val))
 				}
 			} else {
 				if strings.HasPrefix(val, "props.") && len(val) > 6 {
 					val = val[:6] + strings.ToUpper(val[6:7]) + val[7:]
 				}
-				tokens = append(tokens, val)
+				tokens = append(tokens, rawExpr(val, wrapRaw))
 			}
 		}
 		lastEnd = m[1]
 	}
 	if lastEnd < len(input) {
 		if lit := input[lastEnd:]; lit != "" {
-			tokens = append(tokens, fmt.Sprintf("`%s`", lit))
+			tokens = append(tokens, literal(lit))
 		}
 	}
+	return tokens
+}
 
-	if len(tokens) > 1 {
-		return fmt.Sprintf("R(%s)", strings.Join(tokens, ","))
+// rawExpr wraps expr in a Raw conversion when raw is true.
+func rawExpr(expr string, raw bool) string {
+	if !raw {
+		return expr
 	}
-	if len(tokens) == 1 {
-		return tokens[0]
+	return fmt.Sprintf("Raw(%s)", expr)
+}
+
+// processRawElement handles <raw>...</raw>: expressions in its text are written without escaping.
+// The <raw> tag itself is not emitted.
+func processRawElement(n *html.Node, comps map[string]CompInfo) (string, error) {
+	var parts []string
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		var s string
+		if c.Type == html.TextNode {
+			s = processRawText(c.Data)
+		} else {
+			var err error
+			s, err = render(c, comps)
+			if err != nil {
+				return "", err
+			}
+		}
+		if len(s) > 0 {
+			parts = append(parts, s)
+		}
 	}
-	return fmt.Sprintf("`%s`", input)
+	return fmt.Sprintf("R(%s)", strings.Join(parts, ",")), nil
 }
 
 func render(n *html.Node, comps map[string]CompInfo) (string, error) {
@@ -424,14 +747,12 @@ func render(n *html.Node, comps map[string]CompInfo) (string, error) {
 	case html.TextNode:
 		buffer.WriteString(processNode(n.Data))
 
-	// case html.CommentNode:
-	// 	buffer.WriteString("<!--")
-	// 	buffer.WriteString(n.Data)
-	// 	buffer.WriteString("-->")
-	// case html.DoctypeNode:
-	// 	buffer.WriteString("<!DOCTYPE ")
-	// 	buffer.WriteString(n.Data)
-	// 	buffer.WriteString(">")
+	case html.CommentNode:
+		// Comments are server-only and stripped, except <!--! kept --> (written without the
+		// marker) and conditional comments (<!--[if IE]>...<![endif]-->).
+		if text, ok := keptComment(n.Data); ok {
+			buffer.WriteString(fmt.Sprintf("Literal(%q)", "<!--"+text+"-->"))
+		}
 	case html.ElementNode:
 		if n.Data == "for" {
 			s, err := processFor(n, comps)
@@ -448,45 +769,48 @@ func render(n *html.Node, comps map[string]CompInfo) (string, error) {
 		} else if n.Data == "elseif" || n.Data == "else" {
 			// Consumed by a preceding <if>; skip (processIfChain already emitted code)
 			return "", nil
+		} else if n.Data == "raw" {
+			s, err := processRawElement(n, comps)
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(s)
+		} else if n.Data == "await" {
+			s, err := processAwait(n, comps)
+			if err != nil {
+				return "", err
+			}
+			buffer.WriteString(s)
 		} else if n.Data == "slot" {
 			s, err := processSlot(n)
 			if err != nil {
 				return "", err
 			}
 			buffer.WriteString(s)
-		} else {
-
-			childNodes := collectChildNodes(n)
-			s, complete, err := generateProps(n, comps, childNodes)
+		} else if n.Data == "head-item" {
+			s, err := processHeadItem(n, comps)
 			if err != nil {
 				return "", err
 			}
-
 			buffer.WriteString(s)
-
-			if complete {
-				// Custom component with slots/default children already included
-			} else if n.Data == "script" || n.Data == "style" {
-				buffer.WriteString("R(`")
-				for c := n.FirstChild; c != nil; c = c.NextSibling {
-					buffer.WriteString(c.Data)
-				}
-				buffer.WriteString("`))")
-			} else {
-				childs := []string{}
-				for _, c := range childNodes {
-					b, err := render(c, comps)
-					if err != nil {
-						return "", err
-					}
-					if len(b) > 0 {
-						childs = append(childs, b)
-					}
-				}
-				buffer.WriteString(strings.Join(childs, ","))
-				buffer.WriteString(")")
+		} else if isHeadOutlet(n) {
+			s, err := processHeadOutlet(n, comps)
+			if err != nil {
+				return "", err
 			}
-
+			buffer.WriteString(s)
+		} else if isHeadDefault(n) {
+			// Written by the head outlet as one of its defaults.
+			return "", nil
+		} else {
+			out, err := processElement(n, comps)
+			if err != nil {
+				return "", err
+			}
+			if hoistsTitle(n) {
+				out = fmt.Sprintf("HeadItem(%s)", out)
+			}
+			buffer.WriteString(out)
 		}
 
 	case html.DocumentNode:
@@ -513,6 +837,177 @@ func render(n *html.Node, comps map[string]CompInfo) (string, error) {
 	return buffer.String(), nil
 }
 
+// processElement returns the code for an element or component node: elementCode, folded when
+// static and wrapped in Fragment when marked as one.
+func processElement(n *html.Node, comps map[string]CompInfo) (string, error) {
+	fragment, err := takeFragmentAttr(n)
+	if err != nil {
+		return "", err
+	}
+	code, err := elementCode(n, comps)
+	if err != nil {
+		return "", err
+	}
+
+	// Static subtrees are pre-rendered; their code is kept for formatted renders.
+	out, err := foldStatic(n, comps, code)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		out = code
+	}
+	if fragment != "" {
+		out = fmt.Sprintf("Fragment(`%s`,%s)", fragment, out)
+	}
+	return out, nil
+}
+
+// elementCode returns the code for an element or component node without its fragment marker
+// or static folding.
+func elementCode(n *html.Node, comps map[string]CompInfo) (string, error) {
+	var code strings.Builder
+	childNodes := collectChildNodes(n)
+	s, complete, err := generateProps(n, comps, childNodes)
+	if err != nil {
+		return "", err
+	}
+
+	code.WriteString(s)
+
+	if complete {
+		// Custom component with slots/default children already included
+	} else if n.Data == "script" || n.Data == "style" {
+		code.WriteString("Literal(`")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			code.WriteString(c.Data)
+		}
+		code.WriteString("`))")
+	} else {
+		childs := []string{}
+		for _, c := range childNodes {
+			b, err := render(c, comps)
+			if err != nil {
+				return "", err
+			}
+			if len(b) > 0 {
+				childs = append(childs, b)
+			}
+		}
+		code.WriteString(strings.Join(childs, ","))
+		code.WriteString(")")
+	}
+	return code.String(), nil
+}
+
+// fragmentAttr marks an element or component as a named fragment for RenderFragment.
+const fragmentAttr = "fragment"
+
+// takeFragmentAttr removes the fragment="name" marker from n and returns the name.
+func takeFragmentAttr(n *html.Node) (string, error) {
+	for i, a := range n.Attr {
+		if a.Key != fragmentAttr {
+			continue
+		}
+		if a.Val == "" || strings.ContainsAny(a.Val, "{}`") {
+			return "", fmt.Errorf("<%s>: fragment name must be a static name; use id={...} for dynamic fragments", n.Data)
+		}
+		n.Attr = append(n.Attr[:i:i], n.Attr[i+1:]...)
+		return a.Val, nil
+	}
+	return "", nil
+}
+
+// headOutletAttr marks the <meta> NewHtml writes in place of <head-outlet/>: the HTML parser
+// moves unknown elements out of <head>, but keeps a <meta>.
+const headOutletAttr = "data-gohtmlx-head-outlet"
+
+var reHeadOutlet = regexp.MustCompile(`(?i)<head-outlet[ \t]*/?>(?:</head-outlet>)?`)
+
+func isHeadOutlet(n *html.Node) bool {
+	return n.Type == html.ElementNode && n.Data == "meta" && n.Namespace == "" && hasAttr(n, headOutletAttr)
+}
+
+// isHeadDefault reports whether n is a default of a head outlet: a <title>, <meta name|property|
+// http-equiv> or <link rel="canonical"> in the <head> that contains the outlet.
+func isHeadDefault(n *html.Node) bool {
+	p := n.Parent
+	if n.Type != html.ElementNode || n.Namespace != "" || p == nil || p.Data != "head" {
+		return false
+	}
+	switch n.Data {
+	case "title":
+	case "meta":
+		if !hasAttr(n, "name") && !hasAttr(n, "property") && !hasAttr(n, "http-equiv") {
+			return false
+		}
+	case "link":
+		if !strings.EqualFold(getAttr(n, "rel"), "canonical") {
+			return false
+		}
+	default:
+		return false
+	}
+	for c := p.FirstChild; c != nil; c = c.NextSibling {
+		if isHeadOutlet(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// hoistsTitle reports whether n is a <title> outside <head> and <head-item>, which renders as a
+// head item. SVG titles are content.
+func hoistsTitle(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != "title" || n.Namespace != "" {
+		return false
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && (p.Data == "head" || p.Data == "head-item") {
+			return false
+		}
+	}
+	return true
+}
+
+// processHeadItem returns Go code that adds the content of <head-item> to the page's head:
+// HeadItem(items...).
+func processHeadItem(n *html.Node, comps map[string]CompInfo) (string, error) {
+	var items []string
+	for _, c := range collectChildNodes(n) {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		s, err := render(c, comps)
+		if err != nil {
+			return "", err
+		}
+		if s != "" {
+			items = append(items, s)
+		}
+	}
+	return fmt.Sprintf("HeadItem(%s)", strings.Join(items, ",")), nil
+}
+
+// processHeadOutlet returns Go code for a head outlet with the defaults of its <head>:
+// HeadOutlet(defaults...).
+func processHeadOutlet(n *html.Node, comps map[string]CompInfo) (string, error) {
+	var defaults []string
+	if n.Parent != nil {
+		for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+			if !isHeadDefault(c) {
+				continue
+			}
+			s, err := processElement(c, comps)
+			if err != nil {
+				return "", err
+			}
+			defaults = append(defaults, s)
+		}
+	}
+	return fmt.Sprintf("HeadOutlet(%s)", strings.Join(defaults, ",")), nil
+}
+
 // processSlot returns Go code that renders the slot content: R(props.SlotName).
 // The node must be <slot name="..."/> or <slot name="...">; name is required.
 func processSlot(n *html.Node) (string, error) {
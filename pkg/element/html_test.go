@@ -1,6 +1,7 @@
 package element
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -199,3 +200,314 @@ func FuzzProcessRaws(f *testing.F) {
 		_ = processRaws(string(data))
 	})
 }
+
+func TestNewHtml_RawDirective(t *testing.T) {
+	for _, src := range []string{
+		`<div><raw>{props.body}</raw></div>`,
+		`<div>{@html props.body}</div>`,
+	} {
+		h, err := NewHtml([]byte(src))
+		if err != nil {
+			t.Fatalf("NewHtml: %v", err)
+		}
+		out, err := h.RenderGolangCode(map[string]CompInfo{})
+		if err != nil {
+			t.Fatalf("RenderGolangCode: %v", err)
+		}
+		if !strings.Contains(out, "Raw(props.Body)") {
+			t.Errorf("%s: expected Raw(props.Body) in output, got: %s", src, out)
+		}
+		if strings.Contains(out, "`raw`") {
+			t.Errorf("%s: <raw> must not be emitted as an element, got: %s", src, out)
+		}
+	}
+}
+
+func TestNewHtml_VoidElementWithChildren(t *testing.T) {
+	_, err := NewHtml([]byte("<div>\n  <input name=\"q\">text</input>\n</div>"))
+	var se *SourceError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected SourceError, got %T: %v", err, err)
+	}
+	if se.Line != 2 || !strings.Contains(se.Message, "<input>") {
+		t.Errorf("expected line 2 error about <input>, got line %d: %s", se.Line, se.Message)
+	}
+
+	// Void elements without content, self-closing forms, and a redundant end tag are fine.
+	for _, src := range []string{`<p><br>text</p>`, `<input/><img src="x">`, `<meta charset="UTF-8"></meta>`, `<div><input><span>x</span></div>`} {
+		if _, err := NewHtml([]byte(src)); err != nil {
+			t.Errorf("%s: unexpected error: %v", src, err)
+		}
+	}
+}
+
+func TestNewHtml_ValuelessAttributeIsBoolean(t *testing.T) {
+	h, err := NewHtml([]byte(`<button disabled={props.Busy} hidden>go</button>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.Contains(out, "{Key:`hidden`,Value:true}") || !strings.Contains(out, "{Key:`disabled`,Value:props.Busy}") {
+		t.Errorf("expected bare hidden as true and disabled expression, got: %s", out)
+	}
+}
+
+func TestNewHtml_SpreadAttrs(t *testing.T) {
+	h, err := NewHtml([]byte(`<div class="card" {...attrs} id="x">hi</div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.Contains(out, "E(`div`,Spread(AttrList{{Key:`class`,Value:`card`},{Key:`id`,Value:`x`},},attrs),") {
+		t.Errorf("expected Spread over the template attributes, got: %s", out)
+	}
+
+	h, err = NewHtml([]byte(`<div {...props.extra}></div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	if _, err := h.RenderGolangCode(map[string]CompInfo{}); err == nil {
+		t.Error("expected error for unsupported spread expression")
+	}
+}
+
+func TestNewHtml_DocumentGetsDoctype(t *testing.T) {
+	h, err := NewHtml([]byte(`<!DOCTYPE html><html lang="en"><head></head><body>x</body></html>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.HasPrefix(out, "R(Literal(`<!DOCTYPE html>`),Static(\"<html lang=\\\"en\\\">") {
+		t.Errorf("expected doctype before <html>, got: %s", out)
+	}
+
+	h, err = NewHtml([]byte(`<div>x</div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err = h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if strings.Contains(out, "DOCTYPE") {
+		t.Errorf("fragment should not get a doctype, got: %s", out)
+	}
+}
+
+func TestNewHtml_Comments(t *testing.T) {
+	h, err := NewHtml([]byte(`<div><!-- server only --><!--! (c) Example, MIT --><!--[if IE]><p>old</p><![endif]--></div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if strings.Contains(out, "server only") {
+		t.Errorf("plain comment should be stripped, got: %s", out)
+	}
+	if !strings.Contains(out, `Literal("<!-- (c) Example, MIT -->")`) {
+		t.Errorf("expected kept comment without marker, got: %s", out)
+	}
+	if !strings.Contains(out, `Literal("<!--[if IE]><p>old</p><![endif]-->")`) {
+		t.Errorf("expected conditional comment kept, got: %s", out)
+	}
+}
+
+func TestNewHtml_SVGElements(t *testing.T) {
+	h, err := NewHtml([]byte(`<svg viewBox="0 0 24 24" class="icon"><defs><linearGradient id="g"></linearGradient></defs><use xlink:href="#i"/><path d={props.D} /></svg>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	for _, want := range []string{
+		"E(`svg`,AttrList{{Key:`viewBox`,Value:`0 0 24 24`},",
+		"E(`linearGradient`,",
+		"E(`use`,AttrList{{Key:`xlink:href`,Value:`#i`},}",
+		"E(`path`,AttrList{{Key:`d`,Value:props.D},}",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "Comp(") {
+		t.Errorf("SVG tags must not be treated as components, got: %s", out)
+	}
+}
+
+func TestNewHtml_ForeignRootOutsideSVG(t *testing.T) {
+	// A component whose root is an SVG element: the parser sees unknown HTML tags here.
+	h, err := NewHtml([]byte(`<g><clipPath id="c"></clipPath><circle cx="1"/></g>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.Contains(out, "E(`g`,") || !strings.Contains(out, "E(`clipPath`,") || !strings.Contains(out, "E(`circle`,") {
+		t.Errorf("expected SVG elements, got: %s", out)
+	}
+}
+
+func TestNewHtml_CustomElementPassThrough(t *testing.T) {
+	h, err := NewHtml([]byte(`<sl-button variant="primary" {...attrs}><my-icon name="x"></my-icon>Go</sl-button><search><menu></menu></search>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	for _, want := range []string{"E(`sl-button`,Spread(AttrList{{Key:`variant`,Value:`primary`},},attrs),", "E(`my-icon`,", "E(`search`,nil,E(`menu`,nil,))"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in output, got: %s", want, out)
+		}
+	}
+}
+
+func TestNewHtml_UnknownElementIsSourceError(t *testing.T) {
+	h, err := NewHtml([]byte("\n<div>\n  <Buton>x</Buton>\n</div>"))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	_, err = h.RenderGolangCode(map[string]CompInfo{"button2": {Name: "Button2"}})
+	var se *SourceError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected SourceError, got %T: %v", err, err)
+	}
+	if se.Line != 3 || !strings.Contains(se.Message, "unknown element <buton>") {
+		t.Errorf("got line %d, message %q", se.Line, se.Message)
+	}
+}
+
+func TestNewHtml_FragmentMarker(t *testing.T) {
+	h, err := NewHtml([]byte(`<section fragment="results" class="r"><p>{props.X}</p></section>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	if !strings.Contains(out, "Fragment(`results`,E(`section`,AttrList{{Key:`class`,Value:`r`},},E(`p`,nil,R(props.X))))") {
+		t.Errorf("expected fragment marker wrapping the element, got: %s", out)
+	}
+
+	h, err = NewHtml([]byte(`<div fragment={props.Name}></div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	if _, err := h.RenderGolangCode(map[string]CompInfo{}); err == nil {
+		t.Error("expected error for dynamic fragment name")
+	}
+}
+
+func TestNewHtml_Await(t *testing.T) {
+	h, err := NewHtml([]byte(`<div><await><fallback>Loading</fallback><p>{stats(ctx)}</p></await></div>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	want := "Await(R(Literal(`Loading`)), func(ctx Context) Element {\nreturn R(E(`p`,nil,R(stats(ctx))))\n})"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected %s, got: %s", want, out)
+	}
+
+	h, err = NewHtml([]byte(`<await><fallback>a</fallback><fallback>b</fallback></await>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	if _, err := h.RenderGolangCode(map[string]CompInfo{}); err == nil {
+		t.Error("expected error for two fallbacks")
+	}
+}
+
+func TestNewHtml_FoldsStaticSubtrees(t *testing.T) {
+	h, err := NewHtml([]byte(`<main class="{props.Class}"><nav><a href="/">Home &amp; more</a><br></nav><p>{props.Text}</p><svg><path d="M0"/></svg></main>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	// The largest static subtree is folded once; its code is kept as the build function.
+	want := "Static(\"<nav><a href=\\\"/\\\">Home &amp; more</a><br></nav>\", func() Element {\n" +
+		"return E(`nav`,nil,E(`a`,AttrList{{Key:`href`,Value:`/`},},Literal(`Home &amp; more`)),E(`br`,nil,))\n})"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected folded <nav>, got: %s", out)
+	}
+	if strings.Count(out, "Static(") != 1 {
+		t.Errorf("expected only <nav> folded (not <main> with an expression, not SVG), got: %s", out)
+	}
+}
+
+func TestNewHtml_WriterCode(t *testing.T) {
+	h, err := NewHtml([]byte(`<ul class="{props.Class}"><for items={props.Items} as="it"><li>{it}</li></for><if condition={props.More}><li>more</li></if><else>none</else><Card title="x"></Card></ul>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderWriterCode(map[string]CompInfo{"card": {Name: "Card", Props: map[string]string{"title": "title"}}})
+	if err != nil {
+		t.Fatalf("RenderWriterCode: %v", err)
+	}
+	want := "w.Open(`ul`, true)\nw.Attr(`class`, props.Class)\nw.Body()\n" +
+		"for _, it := range props.Items {\nw.Open(`li`, true)\nw.Body()\nw.Value(it)\nw.End()\n}\n" +
+		"if props.More {\nw.Static(\"<li>more</li>\", func() Element {\nreturn E(`li`,nil,Literal(`more`))\n})\n} else {\nw.Text(`none`)\n}\n" +
+		"w.Element(CardComp(Card{Title:`x`,},Attrs{},))\nw.End()\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestNewHtml_HeadOutlet(t *testing.T) {
+	h, err := NewHtml([]byte("<html><head>\n<meta charset=\"UTF-8\">\n<title>Site</title>\n<head-outlet/>\n<link rel=\"stylesheet\" href=\"/a.css\">\n</head><body></body></html>"))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err := h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	// The outlet stays in <head>, with the head's title as its default.
+	want := "Literal(`\n`),HeadOutlet(Static(\"<title>Site</title>\", func() Element {\nreturn E(`title`,nil,Literal(`Site`))\n})),Literal(`\n`),Static(\"<link"
+	if !strings.Contains(out, want) || strings.Count(out, "<title>") != 1 {
+		t.Errorf("expected the title moved into HeadOutlet, got: %s", out)
+	}
+
+	h, err = NewHtml([]byte(`<section><title>{props.Name} - Docs</title><head-item> <meta name="description" content={props.Summary}> </head-item><svg><title>icon</title></svg></section>`))
+	if err != nil {
+		t.Fatalf("NewHtml: %v", err)
+	}
+	out, err = h.RenderGolangCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderGolangCode: %v", err)
+	}
+	want = "R(E(`section`,nil,HeadItem(E(`title`,nil,R(props.Name, Literal(` - Docs`)))),HeadItem(E(`meta`,AttrList{{Key:`name`,Value:`description`},{Key:`content`,Value:props.Summary},},)),E(`svg`,nil,E(`title`,nil,Literal(`icon`)))))"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	out, err = h.RenderWriterCode(map[string]CompInfo{})
+	if err != nil {
+		t.Fatalf("RenderWriterCode: %v", err)
+	}
+	if !strings.Contains(out, "w.Element(HeadItem(E(`title`,nil,R(props.Name, Literal(` - Docs`)))))\n") {
+		t.Errorf("expected the title written as a head item, got: %s", out)
+	}
+}
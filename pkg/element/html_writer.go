@@ -0,0 +1,197 @@
+package element
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// RenderWriterCode returns the body of a component for the writer backend: Go statements that
+// write the template through w, a *Writer, instead of building elements. Text and HTML
+// elements are written directly and <for> and <if> become Go loops and conditionals;
+// components, slots, <raw> and <await> are built as elements and written with w.Element.
+func (h htmlc) RenderWriterCode(comps map[string]CompInfo) (string, error) {
+	var buffer strings.Builder
+	for _, n := range h.nodes {
+		if n.Type == html.ElementNode && n.Data == "html" {
+			buffer.WriteString("w.Text(`<!DOCTYPE html>`)\n")
+		}
+		if err := writeNode(&buffer, n, comps); err != nil {
+			return "", h.locate(err)
+		}
+	}
+	return buffer.String(), nil
+}
+
+// writeNode writes the statements for n to buffer.
+func writeNode(buffer *strings.Builder, n *html.Node, comps map[string]CompInfo) error {
+	switch n.Type {
+	case html.TextNode:
+		for _, t := range textTokens(n.Data) {
+			if lit, ok := strings.CutPrefix(t, "Literal(`"); ok {
+				buffer.WriteString("w.Text(`" + lit + "\n")
+			} else {
+				buffer.WriteString("w.Value(" + t + ")\n")
+			}
+		}
+	case html.CommentNode:
+		if text, ok := keptComment(n.Data); ok {
+			buffer.WriteString(fmt.Sprintf("w.Text(%q)\n", "<!--"+text+"-->"))
+		}
+	case html.DocumentNode:
+		return writeChildren(buffer, n, comps)
+	case html.ElementNode:
+		switch n.Data {
+		case "for":
+			key, as, err := forParams(n)
+			if err != nil {
+				return err
+			}
+			buffer.WriteString(fmt.Sprintf("for _, %s := range %s {\n", as, key))
+			if err := writeChildren(buffer, n, comps); err != nil {
+				return err
+			}
+			buffer.WriteString("}\n")
+		case "if":
+			return writeIfChain(buffer, n, comps)
+		case "elseif", "else":
+			// Written by the preceding <if>.
+		case "raw", "await", "slot", "head-item":
+			return writeElementCode(buffer, n, comps)
+		default:
+			return writeElement(buffer, n, comps)
+		}
+	}
+	return nil
+}
+
+func writeChildren(buffer *strings.Builder, n *html.Node, comps map[string]CompInfo) error {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := writeNode(buffer, c, comps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeIfChain writes <if> and the <elseif> and <else> elements that follow it, as
+// processIfChain groups them.
+func writeIfChain(buffer *strings.Builder, ifNode *html.Node, comps map[string]CompInfo) error {
+	cond, err := getConditionAttr(ifNode)
+	if err != nil {
+		return err
+	}
+	buffer.WriteString(fmt.Sprintf("if %s {\n", processRaws(cond)))
+	if err := writeChildren(buffer, ifNode, comps); err != nil {
+		return err
+	}
+chain:
+	for sib := ifNode.NextSibling; sib != nil; sib = sib.NextSibling {
+		switch sib.Data {
+		case "elseif":
+			c, err := getConditionAttr(sib)
+			if err != nil {
+				return err
+			}
+			buffer.WriteString(fmt.Sprintf("} else if %s {\n", processRaws(c)))
+		case "else":
+			buffer.WriteString("} else {\n")
+		default:
+			break chain
+		}
+		if err := writeChildren(buffer, sib, comps); err != nil {
+			return err
+		}
+		if sib.Data == "else" {
+			break
+		}
+	}
+	buffer.WriteString("}\n")
+	return nil
+}
+
+// writeElementCode writes n built as an element by the tree backend's code.
+func writeElementCode(buffer *strings.Builder, n *html.Node, comps map[string]CompInfo) error {
+	code, err := render(n, comps)
+	if err != nil {
+		return err
+	}
+	buffer.WriteString(fmt.Sprintf("w.Element(%s)\n", code))
+	return nil
+}
+
+// writeElement writes an element or component node. HTML, SVG, MathML and custom elements are
+// written tag by tag, static subtrees as their pre-rendered output.
+func writeElement(buffer *strings.Builder, n *html.Node, comps map[string]CompInfo) error {
+	tag, foreign, isElement, err := elementKind(n, comps)
+	if err != nil {
+		return err
+	}
+	if isHeadDefault(n) {
+		// Written by the head outlet.
+		return nil
+	}
+	// A fragment is an element RenderFragment searches for, so it is built as one; head
+	// outlets and items are elements of their own.
+	if !isElement || hasAttr(n, fragmentAttr) || isHeadOutlet(n) || hoistsTitle(n) {
+		return writeElementCode(buffer, n, comps)
+	}
+	if out, ok, err := staticHTML(n, comps); err != nil {
+		return err
+	} else if ok {
+		code, err := elementCode(n, comps)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(fmt.Sprintf("w.Static(%q, func() Element {\nreturn %s\n})\n", out, code))
+		return nil
+	}
+
+	var content strings.Builder
+	if n.Data == "script" || n.Data == "style" {
+		content.WriteString("w.Text(`")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			content.WriteString(c.Data)
+		}
+		content.WriteString("`)\n")
+	} else {
+		for _, c := range collectChildNodes(n) {
+			if err := writeNode(&content, c, comps); err != nil {
+				return err
+			}
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("w.Open(`%s`, %t)\n", tag, content.Len() > 0))
+	if len(n.Attr) > 0 {
+		list, spread, err := attrListCode(n, foreign)
+		if err != nil {
+			return err
+		}
+		if spread {
+			buffer.WriteString(fmt.Sprintf("w.Attrs(Spread(%s, attrs))\n", list))
+		} else {
+			for _, a := range n.Attr {
+				key := a.Key
+				if foreign {
+					key = foreignAttrKey(a)
+				}
+				buffer.WriteString(fmt.Sprintf("w.Attr(`%s`, %s)\n", key, attrValueCode(a)))
+			}
+		}
+	}
+	buffer.WriteString("w.Body()\n")
+	buffer.WriteString(content.String())
+	buffer.WriteString("w.End()\n")
+	return nil
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package element
+
+// Writer writes markup straight into a render, without building elements. Code generated by
+// the writer backend (transpiler RunOptions.Backend "writer") uses it, and hand-written
+// components can use it through WriteFunc:
+//
+//	element.WriteFunc(func(w *element.Writer) {
+//		w.Open("p", true)
+//		w.Attr("class", "note")
+//		w.Body()
+//		w.Value(name)
+//		w.End()
+//	})
+//
+// Output is the same as for the equivalent elements under every render option: formatting,
+// fragments, foreign content and escaping work as for E and R. A Writer is only valid during
+// the call it is passed to.
+type Writer renderWriter
+
+// WriteFunc returns an Element rendered by fn.
+func WriteFunc(fn func(w *Writer)) Element {
+	return contextElement{write: fn}
+}
+
+// ComponentWriter is ComponentAt for a component written by fn; the writer backend emits it.
+func ComponentWriter(name, file string, line int, fn func(w *Writer)) Element {
+	return contextElement{name: name, file: file, line: line, write: fn}
+}
+
+// openElement is an element started with Open and not yet ended.
+type openElement struct {
+	tag         string
+	hasChildren bool
+	hasEnd      bool
+	foreign     bool
+	// content is set when Body wrote a start tag that has content and an end tag.
+	content     bool
+	prevForeign bool
+	// search is set when the element was opened while RenderFragment searched: its attributes
+	// are kept until Body decides whether it is the fragment (matched).
+	search  bool
+	matched bool
+	attrs   AttrList
+}
+
+func (w *Writer) rw() *renderWriter {
+	return (*renderWriter)(w)
+}
+
+// Context returns the render context.
+func (w *Writer) Context() Context {
+	return w.ctx
+}
+
+// Text writes template text, as Literal.
+func (w *Writer) Text(s string) {
+	Literal(s).renderTo(w.rw())
+}
+
+// Value writes a dynamic value with the conversion table of R: strings are escaped, Elements
+// rendered, and so on.
+func (w *Writer) Value(v any) {
+	rw := w.rw()
+	if rw.ok() {
+		writeItem(rw, v)
+	}
+}
+
+// Element renders el, e.g. a component or slot content.
+func (w *Writer) Element(el Element) {
+	renderChild(w.rw(), el)
+}
+
+// Static writes a pre-rendered subtree, as Static.
+func (w *Writer) Static(html string, build func() Element) {
+	staticElement{html: html, build: build}.renderTo(w.rw())
+}
+
+// Open starts an element: its attributes follow with Attr, then Body, its content, and End.
+// hasChildren reports whether the template gives the element content; empty SVG and MathML
+// elements are written self-closing.
+func (w *Writer) Open(tag string, hasChildren bool) {
+	rw := w.rw()
+	rw.ok()
+	o := openElement{tag: tag, hasChildren: hasChildren}
+	if rw.searching() {
+		o.search = true
+	} else {
+		o.hasEnd, o.foreign = rw.beginTag(tag, hasChildren)
+	}
+	rw.open = append(rw.open, o)
+}
+
+// Attr writes an attribute of the element started by Open, as an AttrList entry of E.
+func (w *Writer) Attr(key string, v any) {
+	rw := w.rw()
+	o := &rw.open[len(rw.open)-1]
+	if o.search {
+		o.attrs = append(o.attrs, Attr{Key: key, Value: v})
+		return
+	}
+	rw.writeElementAttr(Attr{Key: key, Value: v})
+}
+
+// Attrs writes each attribute in list, e.g. the result of Spread.
+func (w *Writer) Attrs(list AttrList) {
+	for _, a := range list {
+		w.Attr(a.Key, a.Value)
+	}
+}
+
+// Body ends the start tag of the element started by Open.
+func (w *Writer) Body() {
+	rw := w.rw()
+	o := &rw.open[len(rw.open)-1]
+	if o.search {
+		if !(element{tag: o.tag, attrs: o.attrs}).matchesFragment(rw) {
+			return
+		}
+		// This is the fragment: write it from its start tag, as renderFragment does.
+		o.matched = true
+		rw.inFragment = true
+		o.hasEnd, o.foreign = rw.beginTag(o.tag, o.hasChildren)
+		for _, a := range o.attrs {
+			rw.writeElementAttr(a)
+		}
+	}
+	if o.content = rw.endStartTag(o.tag, o.hasEnd, o.foreign, o.hasChildren); o.content {
+		o.prevForeign = rw.foreign
+		rw.foreign = o.foreign && o.tag != "foreignObject"
+	}
+}
+
+// End ends the element started by the matching Open, writing its end tag.
+func (w *Writer) End() {
+	rw := w.rw()
+	o := rw.open[len(rw.open)-1]
+	rw.open = rw.open[:len(rw.open)-1]
+	if o.content {
+		rw.foreign = o.prevForeign
+		rw.endTag(o.tag)
+	}
+	if o.matched {
+		rw.inFragment = false
+		rw.fail(errFragmentRendered)
+	}
+}
@@ -0,0 +1,89 @@
+package element
+
+import (
+	"context"
+	"runtime/pprof"
+	"time"
+)
+
+// Instrumenter receives callbacks around each component render, for tracing and metrics.
+// Attach one to the render context with WithInstrumenter; generated components report under
+// their component name. Callbacks run on the rendering goroutine and must be safe for
+// concurrent renders.
+type Instrumenter interface {
+	// ComponentStart is called before a component renders. The returned context becomes the
+	// component's render context (e.g. carrying a trace span); return ctx to keep it.
+	ComponentStart(ctx Context, name string) Context
+	// ComponentEnd is called after the component rendered, with the context returned by
+	// ComponentStart, the time spent including child components, and the bytes it wrote.
+	ComponentEnd(ctx Context, name string, elapsed time.Duration, bytes int)
+}
+
+// ProfileLabel is the pprof label key set to the component name under WithProfileLabels.
+const ProfileLabel = "gohtmlx_component"
+
+type instrumentKey struct{}
+
+// instrumentation is the per-render configuration stored in the context.
+type instrumentation struct {
+	inst   Instrumenter
+	labels bool
+}
+
+// WithInstrumenter returns a context that reports component renders to inst. Pass it to
+// RenderContext (or any render function taking a context).
+func WithInstrumenter(ctx Context, inst Instrumenter) Context {
+	in := instrumentationFrom(ctx)
+	in.inst = inst
+	return context.WithValue(ctx, instrumentKey{}, &in)
+}
+
+// WithProfileLabels returns a context under which each component renders with the pprof label
+// ProfileLabel set to its name, so CPU profiles can be filtered and grouped by component
+// (go tool pprof -tagfocus, -tagshow). Labels cost allocations per component; enable them for
+// profiling rather than on every request.
+func WithProfileLabels(ctx Context) Context {
+	in := instrumentationFrom(ctx)
+	in.labels = true
+	return context.WithValue(ctx, instrumentKey{}, &in)
+}
+
+func instrumentationFrom(ctx Context) instrumentation {
+	if in, ok := ctx.Value(instrumentKey{}).(*instrumentation); ok {
+		return *in
+	}
+	return instrumentation{}
+}
+
+// setContext makes ctx the render context of rw, picking up its instrumentation.
+func (rw *renderWriter) setContext(ctx Context) {
+	rw.ctx, rw.done = ctx, ctx.Done()
+	rw.instr, _ = ctx.Value(instrumentKey{}).(*instrumentation)
+}
+
+// renderComponent renders the component c under the instrumentation of rw, which is set.
+func (rw *renderWriter) renderComponent(c contextElement) {
+	prevCtx, prevDone := rw.ctx, rw.done
+	if rw.instr.labels {
+		pprof.Do(rw.ctx, pprof.Labels(ProfileLabel, c.name), func(ctx context.Context) {
+			rw.ctx = ctx
+			rw.renderInstrumented(c)
+		})
+	} else {
+		rw.renderInstrumented(c)
+	}
+	rw.ctx, rw.done = prevCtx, prevDone
+}
+
+func (rw *renderWriter) renderInstrumented(c contextElement) {
+	inst := rw.instr.inst
+	if inst == nil {
+		c.renderContent(rw)
+		return
+	}
+	ctx := inst.ComponentStart(rw.ctx, c.name)
+	rw.ctx, rw.done = ctx, ctx.Done()
+	start, n := time.Now(), rw.n
+	c.renderContent(rw)
+	inst.ComponentEnd(ctx, c.name, time.Since(start), rw.n-n)
+}
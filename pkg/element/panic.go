@@ -0,0 +1,98 @@
+package element
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+)
+
+// RenderError is the error a render returns when rendering panics, for example on a nil
+// pointer in a template expression. The panic is recovered at the top of the render (Render,
+// RenderContext, RenderWithOptions, RenderFragment) and rendering stops; output written before
+// the panic has already reached the writer.
+//
+// The error locates the panic by component, not by expression: the file and line it reports
+// are where the innermost component is defined (its "define" line), not the line of the
+// expression that panicked. Stack has the Go stack trace, whose frames in the generated code
+// lead to the expression.
+type RenderError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Components is the stack of components being rendered at the panic, outermost first
+	// (Home > AppHeader > NavItem). Empty when the panic happened outside any component.
+	Components []ComponentFrame
+	// Stack is the goroutine stack trace at the panic.
+	Stack []byte
+}
+
+// ComponentFrame is one component on a RenderError's component stack.
+type ComponentFrame struct {
+	Name string
+	// File and Line locate the component's definition (its "define" line) in the .html
+	// sources, with File relative to the transpiler's source directory; they do not locate
+	// expressions inside the component. Empty for components built with Component.
+	File string
+	Line int
+}
+
+func (f ComponentFrame) String() string {
+	if f.File == "" {
+		return f.Name
+	}
+	return fmt.Sprintf("%s (%s:%d)", f.Name, f.File, f.Line)
+}
+
+// Error returns e.g. "element: panic in Home > AppHeader > NavItem (comps/header.html:12):
+// runtime error: invalid memory address or nil pointer dereference".
+func (e *RenderError) Error() string {
+	if len(e.Components) == 0 {
+		return fmt.Sprintf("element: panic during render: %v", e.Value)
+	}
+	names := make([]string, len(e.Components))
+	for i, f := range e.Components {
+		names[i] = f.Name
+	}
+	path := strings.Join(names, " > ")
+	if f := e.Components[len(e.Components)-1]; f.File != "" {
+		path += fmt.Sprintf(" (%s:%d)", f.File, f.Line)
+	}
+	return fmt.Sprintf("element: panic in %s: %v", path, e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, so errors.Is and errors.As see it.
+func (e *RenderError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+var repanic atomic.Bool
+
+// SetRepanic makes renders panic with the *RenderError instead of returning it when on is
+// true. Use it in tests (e.g. from TestMain) so a template panic fails loudly with its
+// component stack rather than as a render error that may go unchecked.
+func SetRepanic(on bool) {
+	repanic.Store(on)
+}
+
+// renderTop renders el as the root of rw, recovering a panic into a *RenderError.
+func (rw *renderWriter) renderTop(el Element) {
+	defer rw.recoverPanic()
+	renderChild(rw, el)
+}
+
+func (rw *renderWriter) recoverPanic() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	err := &RenderError{
+		Value:      v,
+		Components: append([]ComponentFrame(nil), rw.components...),
+		Stack:      debug.Stack(),
+	}
+	if repanic.Load() {
+		panic(err)
+	}
+	rw.err = err
+}
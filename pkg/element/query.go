@@ -0,0 +1,484 @@
+package element
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// ErrNoMatch is returned by Find when no element matches the selector.
+var ErrNoMatch = errors.New("element: no element matches selector")
+
+// Node is an element of rendered output, for asserting on the structure of a component in
+// tests instead of on its HTML string:
+//
+//	link, err := element.Find(Header(), "nav a.nav-cta")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	if link.Attr("href") != "/start" || link.Text() != "Get started" { ... }
+//
+// Nodes come from rendering the element and parsing the output with the HTML parser, so they
+// see exactly what a browser would: components, slots and fragments are expanded, and both
+// codegen backends produce the same tree.
+type Node struct {
+	// Tag is the element name; "" for the root returned by Tree.
+	Tag string
+	// Attrs are the element's attributes in source order, with string values.
+	Attrs AttrList
+	// Children are the child elements; text is available through Text.
+	Children []*Node
+	// Parent is nil for the root.
+	Parent *Node
+
+	nodes []*html.Node // the parsed element, or the top-level nodes for the root
+}
+
+// Tree renders el and returns the root of its output: a Node without a tag whose Children are
+// the top-level elements, parsed with ParseOutput.
+func Tree(el Element) (*Node, error) {
+	var b strings.Builder
+	if _, err := el.Render(&b); err != nil {
+		return nil, err
+	}
+	nodes, err := ParseOutput(b.String())
+	if err != nil {
+		return nil, err
+	}
+	root := &Node{nodes: nodes}
+	for _, n := range nodes {
+		root.addChild(n)
+	}
+	return root, nil
+}
+
+// ParseOutput parses rendered output and returns its top-level nodes. Output starting with a
+// doctype or <html> is parsed as a document, anything else as content that may appear anywhere
+// (e.g. a lone <tr>), as a browser parses <template> content.
+func ParseOutput(s string) ([]*html.Node, error) {
+	head := strings.ToLower(strings.TrimSpace(s[:min(len(s), 64)]))
+	if strings.HasPrefix(head, "<!doctype") || strings.HasPrefix(head, "<html") {
+		doc, err := html.Parse(strings.NewReader(s))
+		if err != nil {
+			return nil, err
+		}
+		var nodes []*html.Node
+		for c := doc.FirstChild; c != nil; c = c.NextSibling {
+			nodes = append(nodes, c)
+		}
+		return nodes, nil
+	}
+	ctx := &html.Node{Type: html.ElementNode, Data: "template", DataAtom: atom.Template}
+	return html.ParseFragment(strings.NewReader(s), ctx)
+}
+
+// addChild adds n, when it is an element, and its child elements to p.
+func (p *Node) addChild(n *html.Node) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	child := &Node{Tag: n.Data, Parent: p, nodes: []*html.Node{n}}
+	for _, a := range n.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+		child.Attrs = append(child.Attrs, Attr{Key: key, Value: a.Val})
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		child.addChild(c)
+	}
+	p.Children = append(p.Children, child)
+}
+
+// Find renders el and returns the first element matching selector, in document order.
+// It fails with ErrNoMatch when nothing matches; see Node.Find for the selectors supported.
+func Find(el Element, selector string) (*Node, error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	root, err := Tree(el)
+	if err != nil {
+		return nil, err
+	}
+	if found := root.findAll(sel, true); len(found) > 0 {
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrNoMatch, selector)
+}
+
+// FindAll renders el and returns every element matching selector, in document order.
+func FindAll(el Element, selector string) ([]*Node, error) {
+	sel, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	root, err := Tree(el)
+	if err != nil {
+		return nil, err
+	}
+	return root.findAll(sel, false), nil
+}
+
+// Find returns the first element under n matching selector, or nil. Selectors are the CSS
+// subset useful in tests: type (a, *), #id, .class, [attr], [attr=v], [attr~=v], [attr^=v],
+// [attr$=v] and [attr*=v], compounds of these (a.nav-cta[href]), the descendant (space) and
+// child (>) combinators, and comma-separated lists. An invalid selector panics, as
+// regexp.MustCompile does; selectors in tests are constants.
+func (n *Node) Find(selector string) *Node {
+	if found := n.findAll(mustParseSelector(selector), true); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// FindAll returns every element under n matching selector, in document order.
+func (n *Node) FindAll(selector string) []*Node {
+	return n.findAll(mustParseSelector(selector), false)
+}
+
+func (n *Node) findAll(sel selectorList, first bool) []*Node {
+	var found []*Node
+	var walk func(*Node) bool
+	walk = func(p *Node) bool {
+		for _, c := range p.Children {
+			if sel.matches(c) {
+				found = append(found, c)
+				if first {
+					return false
+				}
+			}
+			if !walk(c) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(n)
+	return found
+}
+
+// Attr returns the value of attribute key, or "" when n does not have it.
+func (n *Node) Attr(key string) string {
+	v, _ := n.attr(key)
+	return v
+}
+
+// HasAttr reports whether n has attribute key, e.g. a boolean attribute such as disabled.
+func (n *Node) HasAttr(key string) bool {
+	_, ok := n.attr(key)
+	return ok
+}
+
+func (n *Node) attr(key string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Key == key {
+			return a.Value.(string), true
+		}
+	}
+	return "", false
+}
+
+// Text returns the text content of n with runs of whitespace collapsed to one space and
+// leading and trailing whitespace removed, so template indentation does not matter.
+func (n *Node) Text() string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(h *html.Node) {
+		if h.Type == html.TextNode {
+			b.WriteString(h.Data)
+		}
+		for c := h.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, h := range n.nodes {
+		walk(h)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// HTML returns the outer HTML of n as parsed (the whole output for the root).
+func (n *Node) HTML() string {
+	var b strings.Builder
+	for _, h := range n.nodes {
+		_ = html.Render(&b, h)
+	}
+	return b.String()
+}
+
+// selectorList is a parsed comma-separated selector list.
+type selectorList [][]compoundSelector
+
+// compoundSelector is one compound of a complex selector, e.g. a.nav-cta[href], with the
+// combinator that relates it to the compound before it.
+type compoundSelector struct {
+	combinator byte // ' ' (descendant) or '>' (child); 0 for the first compound
+	tag        string
+	id         string
+	classes    []string
+	attrs      []attrSelector
+}
+
+type attrSelector struct {
+	key, op, val string
+}
+
+func (l selectorList) matches(n *Node) bool {
+	for _, sel := range l {
+		if matchComplex(n, sel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchComplex matches the last compound of sel against n and the rest against its ancestors.
+func matchComplex(n *Node, sel []compoundSelector) bool {
+	last := sel[len(sel)-1]
+	if !last.matches(n) {
+		return false
+	}
+	if len(sel) == 1 {
+		return true
+	}
+	rest := sel[:len(sel)-1]
+	if last.combinator == '>' {
+		return n.Parent != nil && n.Parent.Tag != "" && matchComplex(n.Parent, rest)
+	}
+	for p := n.Parent; p != nil && p.Tag != ""; p = p.Parent {
+		if matchComplex(p, rest) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c compoundSelector) matches(n *Node) bool {
+	if c.tag != "" && c.tag != "*" && !strings.EqualFold(c.tag, n.Tag) {
+		return false
+	}
+	if c.id != "" && n.Attr("id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(n.Attr("class"))
+		for _, want := range c.classes {
+			if !contains(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		v, ok := n.attr(a.key)
+		if !ok {
+			return false
+		}
+		switch a.op {
+		case "=":
+			ok = v == a.val
+		case "~=":
+			ok = contains(strings.Fields(v), a.val)
+		case "^=":
+			ok = a.val != "" && strings.HasPrefix(v, a.val)
+		case "$=":
+			ok = a.val != "" && strings.HasSuffix(v, a.val)
+		case "*=":
+			ok = a.val != "" && strings.Contains(v, a.val)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseSelector(s string) selectorList {
+	sel, err := parseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// parseSelector parses the selector subset documented on Node.Find.
+func parseSelector(s string) (selectorList, error) {
+	p := selectorParser{s: s}
+	list, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("element: invalid selector %q: %w", s, err)
+	}
+	return list, nil
+}
+
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *selectorParser) parse() (selectorList, error) {
+	var list selectorList
+	for {
+		sel, err := p.complex()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			return list, nil
+		}
+		if p.s[p.pos] != ',' {
+			return nil, fmt.Errorf("unexpected %q", p.s[p.pos])
+		}
+		p.pos++
+	}
+}
+
+func (p *selectorParser) complex() ([]compoundSelector, error) {
+	var sel []compoundSelector
+	var combinator byte
+	p.skipSpace()
+	for {
+		c, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		c.combinator = combinator
+		sel = append(sel, c)
+		spaced := p.skipSpace()
+		if p.pos == len(p.s) || p.s[p.pos] == ',' {
+			return sel, nil
+		}
+		switch {
+		case p.s[p.pos] == '>':
+			combinator = '>'
+			p.pos++
+			p.skipSpace()
+		case spaced:
+			combinator = ' '
+		default:
+			return nil, fmt.Errorf("unexpected %q", p.s[p.pos])
+		}
+	}
+}
+
+func (p *selectorParser) compound() (compoundSelector, error) {
+	var c compoundSelector
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '*' {
+		c.tag = "*"
+		p.pos++
+	} else {
+		c.tag = p.ident(false)
+	}
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '#':
+			p.pos++
+			if c.id = p.ident(false); c.id == "" {
+				return c, errors.New("expected id after #")
+			}
+		case '.':
+			p.pos++
+			class := p.ident(false)
+			if class == "" {
+				return c, errors.New("expected class after .")
+			}
+			c.classes = append(c.classes, class)
+		case '[':
+			p.pos++
+			a, err := p.attr()
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, a)
+		default:
+			if p.pos == start {
+				return c, fmt.Errorf("unexpected %q", p.s[p.pos])
+			}
+			return c, nil
+		}
+	}
+	if p.pos == start {
+		return c, errors.New("expected a selector")
+	}
+	return c, nil
+}
+
+// attr parses an attribute selector after its "[".
+func (p *selectorParser) attr() (attrSelector, error) {
+	p.skipSpace()
+	a := attrSelector{key: p.ident(true)}
+	if a.key == "" {
+		return a, errors.New("expected attribute name after [")
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == ']' {
+		p.pos++
+		return a, nil
+	}
+	for _, op := range []string{"=", "~=", "^=", "$=", "*="} {
+		if strings.HasPrefix(p.s[p.pos:], op) {
+			a.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return a, fmt.Errorf("unsupported attribute selector in [%s", p.s[p.pos-len(a.key):])
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) && (p.s[p.pos] == '"' || p.s[p.pos] == '\'') {
+		quote := p.s[p.pos]
+		end := strings.IndexByte(p.s[p.pos+1:], quote)
+		if end < 0 {
+			return a, errors.New("unterminated string")
+		}
+		a.val = p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		a.val = p.ident(false)
+	}
+	p.skipSpace()
+	if p.pos == len(p.s) || p.s[p.pos] != ']' {
+		return a, errors.New("expected ]")
+	}
+	p.pos++
+	return a, nil
+}
+
+// ident parses a name; colon allows namespaced attribute names such as xlink:href.
+func (p *selectorParser) ident(colon bool) string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || colon && c == ':' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte(" \t\n\r\f", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
@@ -0,0 +1,143 @@
+package element
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Static is a subtree without expressions that the transpiler rendered ahead of time: html is
+// its output, build returns the same subtree as elements. A plain render writes html as is;
+// build is used when the output depends on how it is rendered (RenderWithOptions formatting,
+// RenderFragment searching, inside SVG or MathML).
+func Static(html string, build func() Element) Element {
+	return staticElement{html: html, build: build}
+}
+
+type staticElement struct {
+	html  string
+	build func() Element
+}
+
+func (s staticElement) Render(w io.Writer) (int, error) {
+	return renderRoot(w, s)
+}
+
+func (s staticElement) RenderContext(ctx context.Context, w io.Writer) (int, error) {
+	return renderRootContext(ctx, w, s)
+}
+
+func (s staticElement) renderTo(rw *renderWriter) {
+	if rw.format != nil || rw.foreign || rw.inAttr || rw.searching() {
+		renderChild(rw, s.build())
+		return
+	}
+	rw.write(s.html)
+}
+
+// controlElements are the template tags that generate code rather than markup.
+var controlElements = map[string]bool{
+	"for": true, "if": true, "elseif": true, "else": true, "raw": true, "slot": true,
+	"await": true, "fallback": true, "head-item": true,
+}
+
+// foldStatic returns the Static code for element n when n is the root of a static subtree, or
+// "" when n renders as code. code is the generated code for n, kept as Static's build. Only
+// the largest static subtrees are folded: an element inside a static element is left to it.
+func foldStatic(n *html.Node, comps map[string]CompInfo, code string) (string, error) {
+	if p := n.Parent; p != nil && p.Type == html.ElementNode {
+		if _, ok := staticTree(p, comps); ok {
+			return "", nil
+		}
+	}
+	out, ok, err := staticHTML(n, comps)
+	if err != nil || !ok {
+		return "", err
+	}
+	return fmt.Sprintf("Static(%q, func() Element {\nreturn %s\n})", out, code), nil
+}
+
+// staticHTML returns the output of element n when n is static.
+func staticHTML(n *html.Node, comps map[string]CompInfo) (string, bool, error) {
+	el, ok := staticTree(n, comps)
+	if !ok {
+		return "", false, nil
+	}
+	var b strings.Builder
+	if _, err := el.Render(&b); err != nil {
+		return "", false, err
+	}
+	return b.String(), true, nil
+}
+
+// staticTree returns the elements the generated code builds for n when n is static: an HTML
+// or custom element whose attributes and content have no expressions, components or control
+// elements. It mirrors the code generation for those nodes; the fragment marker, which is not
+// rendered, is ignored.
+func staticTree(n *html.Node, comps map[string]CompInfo) (Element, bool) {
+	tag := strings.TrimSpace(n.Data)
+	if n.Type != html.ElementNode || n.Namespace != "" || controlElements[tag] || isHeadOutlet(n) || hoistsTitle(n) {
+		return nil, false
+	}
+	if !isStandard(tag) {
+		if _, isComp := comps[n.Data]; isComp || isForeignTag(tag) || !isCustomElement(tag) {
+			return nil, false
+		}
+	}
+	var attrs AttrSource
+	if len(n.Attr) > 0 {
+		list := AttrList{}
+		for _, a := range n.Attr {
+			if a.Key == fragmentAttr {
+				continue
+			}
+			if isSpread(a.Key) || strings.ContainsAny(a.Key+a.Val, "{}`") {
+				return nil, false
+			}
+			if a.Val == "" {
+				list = append(list, Attr{Key: a.Key, Value: true})
+			} else {
+				list = append(list, Attr{Key: a.Key, Value: a.Val})
+			}
+		}
+		attrs = list
+	}
+	var children []Element
+	if tag == "script" || tag == "style" {
+		var text strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			text.WriteString(c.Data)
+		}
+		if strings.Contains(text.String(), "`") {
+			return nil, false
+		}
+		return E(tag, attrs, Literal(text.String())), true
+	}
+	for _, c := range collectChildNodes(n) {
+		switch c.Type {
+		case html.TextNode:
+			if strings.ContainsAny(c.Data, "{}`") {
+				return nil, false
+			}
+			if c.Data != "" {
+				children = append(children, Literal(literalEscaper.Replace(c.Data)))
+			}
+		case html.CommentNode:
+			if text, ok := keptComment(c.Data); ok {
+				children = append(children, Literal("<!--"+text+"-->"))
+			}
+		case html.ElementNode:
+			el, ok := staticTree(c, comps)
+			if !ok {
+				return nil, false
+			}
+			children = append(children, el)
+		default:
+			return nil, false
+		}
+	}
+	return E(tag, attrs, children...), true
+}
@@ -1,116 +1,152 @@
 package element
 
-func isStandard(tag string) bool {
-	htmlElements := map[string]bool{
-		"a":          true,
-		"abbr":       true,
-		"address":    true,
-		"area":       true,
-		"article":    true,
-		"aside":      true,
-		"audio":      true,
-		"b":          true,
-		"base":       true,
-		"bdi":        true,
-		"bdo":        true,
-		"blockquote": true,
-		"body":       true,
-		"br":         true,
-		"button":     true,
-		"canvas":     true,
-		"caption":    true,
-		"cite":       true,
-		"code":       true,
-		"col":        true,
-		"colgroup":   true,
-		"data":       true,
-		"datalist":   true,
-		"dd":         true,
-		"del":        true,
-		"details":    true,
-		"dfn":        true,
-		"dialog":     true,
-		"div":        true,
-		"dl":         true,
-		"dt":         true,
-		"em":         true,
-		"embed":      true,
-		"fieldset":   true,
-		"figcaption": true,
-		"figure":     true,
-		"footer":     true,
-		"form":       true,
-		"h1":         true,
-		"h2":         true,
-		"h3":         true,
-		"h4":         true,
-		"h5":         true,
-		"h6":         true,
-		"head":       true,
-		"header":     true,
-		"hr":         true,
-		"html":       true,
-		"i":          true,
-		"iframe":     true,
-		"img":        true,
-		"input":      true,
-		"ins":        true,
-		"kbd":        true,
-		"label":      true,
-		"legend":     true,
-		"li":         true,
-		"link":       true,
-		"main":       true,
-		"map":        true,
-		"mark":       true,
-		"meta":       true,
-		"meter":      true,
-		"nav":        true,
-		"noscript":   true,
-		"object":     true,
-		"ol":         true,
-		"optgroup":   true,
-		"option":     true,
-		"output":     true,
-		"p":          true,
-		"picture":    true,
-		"pre":        true,
-		"progress":   true,
-		"q":          true,
-		"rp":         true,
-		"rt":         true,
-		"ruby":       true,
-		"s":          true,
-		"samp":       true,
-		"script":     true,
-		"section":    true,
-		"select":     true,
-		"small":      true,
-		"source":     true,
-		"span":       true,
-		"strong":     true,
-		"style":      true,
-		"sub":        true,
-		"summary":    true,
-		"sup":        true,
-		"table":      true,
-		"tbody":      true,
-		"td":         true,
-		"template":   true,
-		"textarea":   true,
-		"tfoot":      true,
-		"th":         true,
-		"thead":      true,
-		"time":       true,
-		"title":      true,
-		"tr":         true,
-		"track":      true,
-		"u":          true,
-		"ul":         true,
-		"var":        true,
-		"video":      true,
-		"wbr":        true,
-	}
+import "strings"
+
+// htmlElements are the standard HTML element names; other tags are components, SVG/MathML
+// elements, or custom elements.
+var htmlElements = map[string]bool{
+	"a":          true,
+	"abbr":       true,
+	"address":    true,
+	"area":       true,
+	"article":    true,
+	"aside":      true,
+	"audio":      true,
+	"b":          true,
+	"base":       true,
+	"bdi":        true,
+	"bdo":        true,
+	"blockquote": true,
+	"body":       true,
+	"br":         true,
+	"button":     true,
+	"canvas":     true,
+	"caption":    true,
+	"cite":       true,
+	"code":       true,
+	"col":        true,
+	"colgroup":   true,
+	"data":       true,
+	"datalist":   true,
+	"dd":         true,
+	"del":        true,
+	"details":    true,
+	"dfn":        true,
+	"dialog":     true,
+	"div":        true,
+	"dl":         true,
+	"dt":         true,
+	"em":         true,
+	"embed":      true,
+	"fieldset":   true,
+	"figcaption": true,
+	"figure":     true,
+	"footer":     true,
+	"form":       true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"head":       true,
+	"header":     true,
+	"hgroup":     true,
+	"hr":         true,
+	"html":       true,
+	"i":          true,
+	"iframe":     true,
+	"img":        true,
+	"input":      true,
+	"ins":        true,
+	"kbd":        true,
+	"label":      true,
+	"legend":     true,
+	"li":         true,
+	"link":       true,
+	"main":       true,
+	"map":        true,
+	"mark":       true,
+	"menu":       true,
+	"meta":       true,
+	"meter":      true,
+	"nav":        true,
+	"noscript":   true,
+	"object":     true,
+	"ol":         true,
+	"optgroup":   true,
+	"option":     true,
+	"output":     true,
+	"p":          true,
+	"picture":    true,
+	"pre":        true,
+	"progress":   true,
+	"q":          true,
+	"rp":         true,
+	"rt":         true,
+	"ruby":       true,
+	"s":          true,
+	"samp":       true,
+	"script":     true,
+	"search":     true,
+	"section":    true,
+	"select":     true,
+	"small":      true,
+	"source":     true,
+	"span":       true,
+	"strong":     true,
+	"style":      true,
+	"sub":        true,
+	"summary":    true,
+	"sup":        true,
+	"table":      true,
+	"tbody":      true,
+	"td":         true,
+	"template":   true,
+	"textarea":   true,
+	"tfoot":      true,
+	"th":         true,
+	"thead":      true,
+	"time":       true,
+	"title":      true,
+	"tr":         true,
+	"track":      true,
+	"u":          true,
+	"ul":         true,
+	"var":        true,
+	"video":      true,
+	"wbr":        true,
+}
 
+func isStandard(tag string) bool {
 	return htmlElements[tag]
 }
+
+// isCustomElement reports whether tag is a valid custom element name (it contains a hyphen,
+// e.g. sl-button). Unknown custom elements render as plain elements.
+func isCustomElement(tag string) bool {
+	return strings.Contains(tag, "-")
+}
+
+// voidElements are the HTML elements that have no end tag and cannot have children.
+var voidElements = map[string]bool{
+	"area":   true,
+	"base":   true,
+	"br":     true,
+	"col":    true,
+	"embed":  true,
+	"hr":     true,
+	"img":    true,
+	"input":  true,
+	"link":   true,
+	"meta":   true,
+	"source": true,
+	"track":  true,
+	"wbr":    true,
+}
+
+// IsVoid reports whether tag is an HTML void element (br, img, input, meta, ...), which has no
+// end tag or content.
+func IsVoid(tag string) bool {
+	return voidElements[tag]
+}
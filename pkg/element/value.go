@@ -0,0 +1,155 @@
+package element
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/abdheshnayak/gohtmlx/pkg/utils"
+)
+
+// writeValue renders a dynamic value from R or an attribute. The conversion table, in order:
+//
+//	nil, nil pointer, nil interface    nothing
+//	Literal, Raw                       written as-is (trusted)
+//	Element, []Element                 rendered
+//	string, []byte                     escaped text
+//	bool                               "true" / "false"
+//	int*, uint*, uintptr               decimal
+//	float32, float64                   shortest text that round-trips, as encoding/json
+//	                                   (1.5, 100, 1e-7, 1e+21); NaN, +Inf, -Inf as is
+//	complex64, complex128              strconv.FormatComplex, shortest form
+//	time.Time                          RFC 3339 (time.RFC3339Nano when it has sub-seconds)
+//	error                              Error(), escaped
+//	fmt.Stringer                       String(), escaped
+//	named types (type ID int64, ...)   by their underlying kind
+//	pointers                           the value pointed to
+//	slices and arrays                  each item in turn; separated by a space in attributes
+//	anything else (maps, structs, ...) logged as an error and written with %v, escaped
+func writeValue(rw *renderWriter, v any) {
+	switch v := v.(type) {
+	case nil:
+	case Literal:
+		rw.writeTrusted(string(v))
+	case Raw:
+		rw.writeRaw(string(v))
+	case string:
+		rw.writeEscaped(v)
+	case Element:
+		if !isNilPointer(v) {
+			renderChild(rw, v)
+		}
+	case []Element:
+		for _, child := range v {
+			renderChild(rw, child)
+		}
+	case int:
+		rw.writeInt(int64(v))
+	case int64:
+		rw.writeInt(v)
+	case float64:
+		rw.writeFloat(v, 64)
+	case bool:
+		rw.writeBool(v)
+	case []byte:
+		rw.writeEscaped(string(v))
+	case time.Time:
+		rw.writeTime(v)
+	case error:
+		if !isNilPointer(v) {
+			rw.writeEscaped(v.Error())
+		}
+	case fmt.Stringer:
+		if !isNilPointer(v) {
+			rw.writeEscaped(v.String())
+		}
+	case []string:
+		for i, s := range v {
+			if i > 0 && rw.inAttr {
+				rw.write(" ")
+			}
+			rw.writeEscaped(s)
+		}
+	default:
+		writeReflect(rw, reflect.ValueOf(v))
+	}
+}
+
+// isNilPointer reports whether v is a typed nil pointer, e.g. a nil *url.URL, whose methods
+// would dereference it.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// writeReflect handles the rest of the table by kind: other numeric types, named types,
+// pointers, and slices. Values that reach it may still implement Element or Stringer
+// (e.g. through a pointer), so non-pointer items go back through writeValue.
+func writeReflect(rw *renderWriter, rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.String:
+		rw.writeEscaped(rv.String())
+	case reflect.Bool:
+		rw.writeBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		rw.writeInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		rw.writeUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		rw.writeFloat(rv.Float(), rv.Type().Bits())
+	case reflect.Complex64, reflect.Complex128:
+		rw.textStart()
+		rw.write(strconv.FormatComplex(rv.Complex(), 'g', -1, rv.Type().Bits()))
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return
+		}
+		writeValue(rw, rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			rw.writeEscaped(string(rv.Bytes()))
+			return
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 && rw.inAttr {
+				rw.write(" ")
+			}
+			writeValue(rw, rv.Index(i).Interface())
+		}
+	default:
+		utils.Log.Error("unsupported value type", "type", rv.Type().String())
+		rw.writeEscaped(fmt.Sprintf("%v", rv.Interface()))
+	}
+}
+
+// appendFloat formats f the way encoding/json does: the shortest representation that
+// round-trips, in plain notation unless the exponent is very small or very large.
+func appendFloat(b []byte, f float64, bitSize int) []byte {
+	if math.IsNaN(f) {
+		return append(b, "NaN"...)
+	}
+	if math.IsInf(f, 0) {
+		if f > 0 {
+			return append(b, "+Inf"...)
+		}
+		return append(b, "-Inf"...)
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bitSize == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bitSize == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bitSize)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		if n := len(b); n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
@@ -0,0 +1,324 @@
+package element
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// renderWriter is the destination of one Render call. Elements write into it directly instead of
+// building their own buffers, so a page is written once regardless of nesting depth. It buffers
+// writes to the caller's writer, counts bytes, and keeps the first error: once a write fails,
+// further writes are dropped and rendering stops at the next child. Rendering also stops when
+// ctx is cancelled; elements check between children.
+//
+// renderWriters and their buffers are pooled; one is only valid until finish returns.
+type renderWriter struct {
+	w    io.Writer
+	buf  *bufio.Writer // nil when w is already in memory or buffered
+	n    int
+	err  error
+	ctx  context.Context
+	done <-chan struct{} // ctx.Done(), nil when ctx can never be cancelled
+
+	// inAttr is set while an Element is rendered as an attribute value: trusted text then has
+	// its double quotes encoded so it cannot end the attribute.
+	inAttr bool
+	// foreign is set while rendering inside <svg> or <math> (outside <foreignObject>): empty
+	// elements there are written self-closing.
+	foreign bool
+	// inTag is set while a start tag and its attributes are written; formatting applies to
+	// content only.
+	inTag bool
+	// format is set by RenderWithOptions; nil renders whitespace as written.
+	format *formatState
+	// instr is the instrumentation attached to ctx with WithInstrumenter or WithProfileLabels.
+	instr *instrumentation
+	// fragment is the name RenderFragment looks for; inFragment is set while it is rendered.
+	fragment   string
+	inFragment bool
+	// stream is set by RenderWithOptions with Stream; Await content then renders concurrently.
+	stream *stream
+	// head is set once a HeadOutlet rendered; HeadItem adds to it until resolveHead.
+	head *headState
+	// headItems counts the HeadItems rendered, with or without an outlet, so Cached can tell
+	// whether its content rendered any.
+	headItems int
+	// open is the stack of elements started with Writer.Open.
+	open []openElement
+	// components is the stack of named components being rendered, outermost first.
+	components []ComponentFrame
+	// scratch is reused for formatting numbers without allocating.
+	scratch []byte
+}
+
+// renderer is implemented by the package's own elements so nested rendering can share the
+// parent's renderWriter without going through Render.
+type renderer interface {
+	renderTo(rw *renderWriter)
+}
+
+const renderBufferSize = 4096
+
+var (
+	renderWriterPool = sync.Pool{New: func() any { return &renderWriter{scratch: make([]byte, 0, 64)} }}
+	bufioPool        = sync.Pool{New: func() any { return bufio.NewWriterSize(nil, renderBufferSize) }}
+)
+
+func newRenderWriter(ctx context.Context, w io.Writer) *renderWriter {
+	rw := renderWriterPool.Get().(*renderWriter)
+	rw.w = w
+	rw.setContext(ctx)
+	switch w.(type) {
+	case *bytes.Buffer, *strings.Builder, *bufio.Writer:
+	default:
+		rw.buf = bufioPool.Get().(*bufio.Writer)
+		rw.buf.Reset(w)
+	}
+	return rw
+}
+
+func (rw *renderWriter) Write(p []byte) (int, error) {
+	if rw.err != nil {
+		return 0, rw.err
+	}
+	var n int
+	if rw.buf != nil {
+		n, rw.err = rw.buf.Write(p)
+	} else {
+		n, rw.err = rw.w.Write(p)
+	}
+	rw.n += n
+	return n, rw.err
+}
+
+func (rw *renderWriter) WriteString(s string) (int, error) {
+	if rw.err != nil {
+		return 0, rw.err
+	}
+	var n int
+	if rw.buf != nil {
+		n, rw.err = rw.buf.WriteString(s)
+	} else {
+		n, rw.err = io.WriteString(rw.w, s)
+	}
+	rw.n += n
+	return n, rw.err
+}
+
+// write writes s; failures are kept in rw.err for the caller of Render.
+func (rw *renderWriter) write(s string) {
+	_, _ = rw.WriteString(s)
+}
+
+// writeTrusted writes template text or trusted markup verbatim, encoding only double quotes
+// when rendering an attribute value.
+func (rw *renderWriter) writeTrusted(s string) {
+	if rw.inAttr {
+		rw.writeEscapedSet(s, true)
+		return
+	}
+	if rw.formatting() {
+		rw.formatText(s, false)
+		return
+	}
+	rw.write(s)
+}
+
+// writeRaw writes Raw markup. Unlike template text it is never reformatted.
+func (rw *renderWriter) writeRaw(s string) {
+	if rw.inAttr {
+		rw.writeEscapedSet(s, true)
+		return
+	}
+	rw.textStart()
+	rw.write(s)
+}
+
+// writeEscaped writes s HTML-escaped without allocating.
+func (rw *renderWriter) writeEscaped(s string) {
+	if rw.formatting() {
+		rw.formatText(s, true)
+		return
+	}
+	rw.writeEscapedSet(s, false)
+}
+
+// formatting reports whether text written now is subject to RenderWithOptions formatting.
+func (rw *renderWriter) formatting() bool {
+	return rw.format != nil && !rw.inTag
+}
+
+// textStart is called before writing text that is not reformatted (numbers, Raw) so pending
+// formatting whitespace is written first.
+func (rw *renderWriter) textStart() {
+	if rw.formatting() && rw.format.preserve == 0 {
+		rw.beforeContent()
+	}
+}
+
+// writeEscapedSet writes s with special characters encoded. With quotesOnly, only double
+// quotes are encoded (s is already-escaped markup placed in an attribute).
+func (rw *renderWriter) writeEscapedSet(s string, quotesOnly bool) {
+	last := 0
+	for i := 0; i < len(s); i++ {
+		var esc string
+		switch c := s[i]; {
+		case c == '"':
+			esc = "&#34;"
+		case quotesOnly:
+			continue
+		case c == '&':
+			esc = "&amp;"
+		case c == '<':
+			esc = "&lt;"
+		case c == '>':
+			esc = "&gt;"
+		case c == '\'':
+			esc = "&#39;"
+		case c == nbspChar[0] && i+1 < len(s) && s[i+1] == nbspChar[1]:
+			rw.write(s[last:i])
+			rw.write("&nbsp;")
+			i++
+			last = i + 1
+			continue
+		default:
+			continue
+		}
+		rw.write(s[last:i])
+		rw.write(esc)
+		last = i + 1
+	}
+	rw.write(s[last:])
+}
+
+func (rw *renderWriter) writeInt(v int64) {
+	rw.textStart()
+	rw.scratch = strconv.AppendInt(rw.scratch[:0], v, 10)
+	_, _ = rw.Write(rw.scratch)
+}
+
+func (rw *renderWriter) writeUint(v uint64) {
+	rw.textStart()
+	rw.scratch = strconv.AppendUint(rw.scratch[:0], v, 10)
+	_, _ = rw.Write(rw.scratch)
+}
+
+func (rw *renderWriter) writeFloat(v float64, bitSize int) {
+	rw.textStart()
+	rw.scratch = appendFloat(rw.scratch[:0], v, bitSize)
+	_, _ = rw.Write(rw.scratch)
+}
+
+func (rw *renderWriter) writeTime(t time.Time) {
+	rw.textStart()
+	layout := time.RFC3339
+	if t.Nanosecond() != 0 {
+		layout = time.RFC3339Nano
+	}
+	rw.scratch = t.AppendFormat(rw.scratch[:0], layout)
+	_, _ = rw.Write(rw.scratch)
+}
+
+func (rw *renderWriter) writeBool(v bool) {
+	rw.textStart()
+	if v {
+		rw.write("true")
+	} else {
+		rw.write("false")
+	}
+}
+
+// ok reports whether rendering should continue: no write has failed and ctx is not cancelled.
+func (rw *renderWriter) ok() bool {
+	if rw.err != nil {
+		return false
+	}
+	if rw.done != nil {
+		select {
+		case <-rw.done:
+			rw.err = rw.ctx.Err()
+			return false
+		default:
+		}
+	}
+	return true
+}
+
+// fail records err as the render error unless an earlier one is already set.
+func (rw *renderWriter) fail(err error) {
+	if rw.err == nil {
+		rw.err = err
+	}
+}
+
+// finish flushes buffered output, returns the number of bytes that reached the caller's writer,
+// and releases rw to the pool.
+func (rw *renderWriter) finish() (int, error) {
+	rw.resolveHead()
+	n := rw.n
+	if rw.buf != nil {
+		if err := rw.buf.Flush(); err != nil {
+			rw.fail(err)
+		}
+		n -= rw.buf.Buffered()
+		rw.buf.Reset(nil)
+		bufioPool.Put(rw.buf)
+	}
+	err := rw.err
+	clear(rw.open)
+	*rw = renderWriter{scratch: rw.scratch[:0], components: rw.components[:0], open: rw.open[:0]}
+	renderWriterPool.Put(rw)
+	return n, err
+}
+
+// renderChild writes el into rw, sharing rw with the package's elements and falling back to
+// Render for other Element implementations.
+func renderChild(rw *renderWriter, el Element) {
+	if el == nil || !rw.ok() {
+		return
+	}
+	if r, ok := el.(renderer); ok {
+		r.renderTo(rw)
+	} else if rw.searching() {
+		// Other Elements cannot be searched for a fragment without writing them.
+		return
+	} else if _, err := el.Render(rw); err != nil {
+		rw.fail(err)
+	}
+	if rw.head != nil {
+		rw.releaseHead()
+	}
+}
+
+// renderRoot implements Render for the package's elements: when w is the renderWriter of an
+// enclosing render it is reused (keeping its context), otherwise a new one is created with
+// context.Background() and flushed at the end.
+func renderRoot(w io.Writer, el Element) (int, error) {
+	if rw, ok := w.(*renderWriter); ok {
+		start := rw.n
+		el.(renderer).renderTo(rw)
+		return rw.n - start, rw.err
+	}
+	return renderRootContext(context.Background(), w, el)
+}
+
+// renderRootContext implements RenderContext: like renderRoot, but the subtree renders under ctx.
+func renderRootContext(ctx context.Context, w io.Writer, el Element) (int, error) {
+	if rw, ok := w.(*renderWriter); ok {
+		prevCtx := rw.ctx
+		rw.setContext(ctx)
+		defer rw.setContext(prevCtx)
+		start := rw.n
+		el.(renderer).renderTo(rw)
+		return rw.n - start, rw.err
+	}
+	rw := newRenderWriter(ctx, w)
+	rw.renderTop(el)
+	return rw.finish()
+}
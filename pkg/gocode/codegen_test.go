@@ -52,6 +52,9 @@ func TestConstructSource(t *testing.T) {
 	if !strings.Contains(out, "func FooComp(") {
 		t.Errorf("output should contain FooComp, got:\n%s", out)
 	}
+	if !strings.Contains(out, `return Component("Foo", func(ctx Context) Element {`) {
+		t.Errorf("component body should be built inside Component, got:\n%s", out)
+	}
 	// Deterministic
 	out2, err := ConstructSource(codes, structs, imports)
 	if err != nil {
@@ -61,3 +64,46 @@ func TestConstructSource(t *testing.T) {
 		t.Error("ConstructSource should be deterministic")
 	}
 }
+
+func TestConstructSourceAt(t *testing.T) {
+	codes := map[string]string{"Foo": "R(E(`div`, Attrs{},))"}
+	structs := []string{"type Foo struct {\n\tAttrs Attrs\n}\n"}
+	sources := map[string]Source{"Foo": {File: "comps/foo.html", Line: 3}}
+	out, err := ConstructSourceAt(codes, sources, structs, nil, "gohtmlxc")
+	if err != nil {
+		t.Fatalf("ConstructSourceAt: %v", err)
+	}
+	if !strings.Contains(out, `return ComponentAt("Foo", "comps/foo.html", 3, func(ctx Context) Element {`) {
+		t.Errorf("component body should carry its source location, got:\n%s", out)
+	}
+}
+
+func TestConstructWriterSource(t *testing.T) {
+	codes := map[string]string{"Foo": "w.Open(`div`, true)\nw.Body()\nw.Value(ctx.Value(\"k\"))\nw.End()\n"}
+	structs := []string{"type Foo struct {\n\tAttrs Attrs\n}\n"}
+	out, err := ConstructWriterSource(codes, map[string]Source{"Foo": {File: "foo.html", Line: 1}}, structs, nil, "gohtmlxc")
+	if err != nil {
+		t.Fatalf("ConstructWriterSource: %v", err)
+	}
+	for _, want := range []string{
+		`return ComponentWriter("Foo", "foo.html", 1, func(w *Writer) {`,
+		"ctx := w.Context()",
+		"func (c Foo) Render(w io.Writer) (int, error) {",
+		"func (c Foo) RenderContext(ctx Context, w io.Writer) (int, error) {",
+		`"io"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %s, got:\n%s", want, out)
+		}
+	}
+
+	// "ctx" in template text is not a use of the render context.
+	codes = map[string]string{"Foo": "w.Text(`The ctx value is `)\nw.Value(props.N)\n"}
+	out, err = ConstructWriterSource(codes, map[string]Source{"Foo": {File: "foo.html", Line: 1}}, structs, nil, "gohtmlxc")
+	if err != nil {
+		t.Fatalf("ConstructWriterSource: %v", err)
+	}
+	if strings.Contains(out, "w.Context()") {
+		t.Errorf("ctx in text should not declare ctx, got:\n%s", out)
+	}
+}
@@ -6,6 +6,8 @@ package gocode
 import (
 	"fmt"
 	"go/format"
+	"go/scanner"
+	"go/token"
 	"sort"
 	"strings"
 
@@ -70,9 +72,32 @@ func ConstructSharedFile(pkg string, imports []string) (string, error) {
 	return string(b), nil
 }
 
+// Source locates a component's definition in the .html sources. Generated components carry it
+// so a panic while rendering reports where the component is defined (see element.RenderError).
+type Source struct {
+	File string // relative to the source directory, slash-separated
+	Line int
+}
+
 // ConstructComponentFile returns the Go code for a single component (package, imports, type, Comp, Get).
 // Each file needs its own import block so Attrs, Element, and user types (e.g. t) are in scope.
 func ConstructComponentFile(pkg string, imports []string, name string, structStr string, codeStr string) (string, error) {
+	return ConstructComponentFileAt(pkg, imports, name, Source{}, structStr, codeStr)
+}
+
+// ConstructComponentFileAt is ConstructComponentFile for a component defined at src.
+func ConstructComponentFileAt(pkg string, imports []string, name string, src Source, structStr string, codeStr string) (string, error) {
+	return constructComponentFile(pkg, imports, name, src, structStr, codeStr, false)
+}
+
+// ConstructWriterComponentFile is ConstructComponentFileAt for the writer backend: codeStr is
+// the statements from element.Html.RenderWriterCode, and the component type gets Render and
+// RenderContext methods so a props value is itself an element.Element.
+func ConstructWriterComponentFile(pkg string, imports []string, name string, src Source, structStr string, codeStr string) (string, error) {
+	return constructComponentFile(pkg, writerImports(imports), name, src, structStr, codeStr, true)
+}
+
+func constructComponentFile(pkg string, imports []string, name string, src Source, structStr string, codeStr string, writer bool) (string, error) {
 	var builder strings.Builder
 	builder.WriteString("package " + pkg + "\n\n")
 	builder.WriteString("import (\n")
@@ -96,11 +121,18 @@ func ConstructComponentFile(pkg string, imports []string, name string, structStr
 	builder.WriteString("\tif props.Attrs == nil {\n")
 	builder.WriteString("\t\tprops.Attrs = Attrs{}\n")
 	builder.WriteString("\t}\n")
-	builder.WriteString(fmt.Sprintf("\treturn %s\n", codeStr))
+	if writer {
+		builder.WriteString(writerBody(name, src, codeStr))
+	} else {
+		builder.WriteString(componentBody(name, src, codeStr))
+	}
 	builder.WriteString("}\n\n")
 	builder.WriteString(fmt.Sprintf("func (c %s) Get(children ...Element) Element {\n", name))
 	builder.WriteString(fmt.Sprintf("\treturn %sComp(c, c.Attrs, children...)\n", name))
 	builder.WriteString("}\n")
+	if writer {
+		builder.WriteString(renderMethods(name))
+	}
 	b, err := format.Source([]byte(builder.String()))
 	if err != nil {
 		return "", err
@@ -108,6 +140,69 @@ func ConstructComponentFile(pkg string, imports []string, name string, structStr
 	return string(b), nil
 }
 
+// componentBody returns the return statement of a component function. The template code is
+// built inside Component so it runs at render time with the render context in scope as ctx,
+// and renders are reported under the component's name. With a known source it uses
+// ComponentAt, so render panics report the component's file and line.
+func componentBody(name string, src Source, codeStr string) string {
+	if src.File == "" {
+		return fmt.Sprintf("\treturn Component(%q, func(ctx Context) Element {\n\t\treturn %s\n\t})\n", name, codeStr)
+	}
+	return fmt.Sprintf("\treturn ComponentAt(%q, %q, %d, func(ctx Context) Element {\n\t\treturn %s\n\t})\n", name, src.File, src.Line, codeStr)
+}
+
+// writerBody is componentBody for the writer backend: the statements in codeStr run at render
+// time with w, and ctx when they use it.
+func writerBody(name string, src Source, codeStr string) string {
+	if usesCtx(codeStr) {
+		// The blank use keeps the declaration valid when every use is shadowed, e.g. by the
+		// ctx parameter of an Await.
+		codeStr = "ctx := w.Context()\n_ = ctx\n" + codeStr
+	}
+	return fmt.Sprintf("\treturn ComponentWriter(%q, %q, %d, func(w *Writer) {\n%s})\n", name, src.File, src.Line, codeStr)
+}
+
+// usesCtx reports whether generated statements refer to the render context: ctx as an
+// identifier, not as text inside the string literals template text becomes.
+func usesCtx(code string) bool {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	s.Init(fset.AddFile("", fset.Base(), len(code)), []byte(code), nil, 0)
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return false
+		case tok == token.IDENT && lit == "ctx":
+			return true
+		}
+	}
+}
+
+// renderMethods returns the Render and RenderContext methods of a writer backend component
+// type, which render the component without children.
+func renderMethods(name string) string {
+	return fmt.Sprintf(`
+func (c %[1]s) Render(w io.Writer) (int, error) {
+	return c.Get().Render(w)
+}
+
+func (c %[1]s) RenderContext(ctx Context, w io.Writer) (int, error) {
+	return RenderContext(ctx, c.Get(), w)
+}
+`, name)
+}
+
+// writerImports returns imports with "io", which writer backend components use.
+func writerImports(imports []string) []string {
+	for _, v := range imports {
+		if strings.HasSuffix(strings.TrimSpace(v), `"io"`) {
+			return imports
+		}
+	}
+	return append(imports[:len(imports):len(imports)], `"io"`)
+}
+
 // ConstructSource generates single-file Go source with package "gohtmlxc". See ConstructSourceWithPkg for custom package name.
 func ConstructSource(codes map[string]string, structs []string, imports []string) (string, error) {
 	return ConstructSourceWithPkg(codes, structs, imports, "gohtmlxc")
@@ -115,6 +210,21 @@ func ConstructSource(codes map[string]string, structs []string, imports []string
 
 // ConstructSourceWithPkg generates a single-file output with the given package name.
 func ConstructSourceWithPkg(codes map[string]string, structs []string, imports []string, pkg string) (string, error) {
+	return ConstructSourceAt(codes, nil, structs, imports, pkg)
+}
+
+// ConstructSourceAt is ConstructSourceWithPkg with the sources of the components in codes.
+func ConstructSourceAt(codes map[string]string, sources map[string]Source, structs []string, imports []string, pkg string) (string, error) {
+	return constructSource(codes, sources, structs, imports, pkg, false)
+}
+
+// ConstructWriterSource is ConstructSourceAt for the writer backend (see
+// ConstructWriterComponentFile).
+func ConstructWriterSource(codes map[string]string, sources map[string]Source, structs []string, imports []string, pkg string) (string, error) {
+	return constructSource(codes, sources, structs, writerImports(imports), pkg, true)
+}
+
+func constructSource(codes map[string]string, sources map[string]Source, structs []string, imports []string, pkg string, writer bool) (string, error) {
 	var builder strings.Builder
 
 	builder.WriteString("package " + pkg + "\n\n")
@@ -155,13 +265,20 @@ func ConstructSourceWithPkg(codes map[string]string, structs []string, imports [
         }
     `)
 
-		builder.WriteString(fmt.Sprintf("\nreturn %s\n", v))
+		if writer {
+			builder.WriteString("\n" + writerBody(k, sources[k], v))
+		} else {
+			builder.WriteString("\n" + componentBody(k, sources[k], v))
+		}
 		builder.WriteString("\n}\n\n")
 
 		builder.WriteString(fmt.Sprintf("func (c %s) Get(children ...Element) Element {\n", k))
 
 		builder.WriteString(fmt.Sprintf("return %sComp(c, c.Attrs, children...)\n", k))
 		builder.WriteString("}\n\n")
+		if writer {
+			builder.WriteString(renderMethods(k) + "\n")
+		}
 	}
 
 	b, err := format.Source([]byte(builder.String()))
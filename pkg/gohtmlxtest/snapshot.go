@@ -0,0 +1,204 @@
+// Package gohtmlxtest provides test helpers for GoHTMLX components. AssertSnapshot compares
+// the rendered output of an element with a snapshot file under testdata/__snapshots__:
+//
+//	func TestHeader(t *testing.T) {
+//		gohtmlxtest.AssertSnapshot(t, gohtmlxc.Header{Title: "Docs"}.Get())
+//	}
+//
+// Run the tests with GOHTMLX_UPDATE_SNAPSHOTS=1 to create or update snapshots, then review
+// and commit them. Output is normalized before comparing, so snapshots only change when the
+// markup does.
+package gohtmlxtest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/abdheshnayak/gohtmlx/pkg/element"
+	"golang.org/x/net/html"
+)
+
+// UpdateEnv is the environment variable that, set to 1, makes AssertSnapshot write snapshots
+// instead of comparing against them.
+const UpdateEnv = "GOHTMLX_UPDATE_SNAPSHOTS"
+
+// SnapshotDir is where snapshots are stored, relative to the package under test.
+var SnapshotDir = filepath.Join("testdata", "__snapshots__")
+
+// AssertSnapshot renders el and compares its normalized output with the snapshot named after
+// the test (testdata/__snapshots__/TestHeader.html; subtests TestHeader__dark.html). A
+// missing or different snapshot fails the test unless UpdateEnv is set.
+func AssertSnapshot(t testing.TB, el element.Element) {
+	t.Helper()
+	AssertNamedSnapshot(t, t.Name(), el)
+}
+
+// AssertNamedSnapshot is AssertSnapshot with an explicit snapshot name, for several
+// snapshots in one test.
+func AssertNamedSnapshot(t testing.TB, name string, el element.Element) {
+	t.Helper()
+	var b bytes.Buffer
+	if _, err := element.RenderContext(context.Background(), el, &b); err != nil {
+		t.Fatalf("gohtmlxtest: render: %v", err)
+		return
+	}
+	got, err := Normalize(b.String())
+	if err != nil {
+		t.Fatalf("gohtmlxtest: parse output: %v", err)
+		return
+	}
+	path := filepath.Join(SnapshotDir, snapshotFileName(name))
+	want, err := os.ReadFile(path)
+	if os.Getenv(UpdateEnv) == "1" {
+		if err == nil && string(want) == got {
+			return
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("gohtmlxtest: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("gohtmlxtest: update snapshot: %v", err)
+			return
+		}
+		t.Logf("gohtmlxtest: updated %s", path)
+		return
+	}
+	if os.IsNotExist(err) {
+		t.Errorf("gohtmlxtest: snapshot %s does not exist. Run with %s=1 to create it.", path, UpdateEnv)
+		return
+	}
+	if err != nil {
+		t.Fatalf("gohtmlxtest: read snapshot: %v", err)
+		return
+	}
+	if string(want) != got {
+		t.Errorf("gohtmlxtest: output differs from snapshot %s. Run with %s=1 to update it.\n%s", path, UpdateEnv, diff(string(want), got))
+	}
+}
+
+// reUnsafe matches characters kept out of snapshot file names.
+var reUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// snapshotFileName returns the file name for a snapshot: subtest separators become "__".
+func snapshotFileName(name string) string {
+	return reUnsafe.ReplaceAllString(strings.ReplaceAll(name, "/", "__"), "_") + ".html"
+}
+
+// Normalize parses rendered HTML with element.ParseOutput and writes it back in a canonical
+// form: one element, text run or comment per line, indented two spaces per level, attributes
+// sorted by name (empty ones bare), and whitespace in text collapsed. The content of pre,
+// textarea, script and style is kept as serialized, on the line of the start tag.
+func Normalize(s string) (string, error) {
+	nodes, err := element.ParseOutput(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		writeNode(&b, n, 0)
+	}
+	return b.String(), nil
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\u00a0", "&nbsp;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", `"`, "&quot;", "\u00a0", "&nbsp;")
+)
+
+func writeNode(b *strings.Builder, n *html.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.Type {
+	case html.DoctypeNode:
+		b.WriteString(indent + "<!DOCTYPE " + n.Data + ">\n")
+	case html.CommentNode:
+		b.WriteString(indent + "<!--" + n.Data + "-->\n")
+	case html.TextNode:
+		if text := strings.Join(strings.FieldsFunc(n.Data, isHTMLSpace), " "); text != "" {
+			b.WriteString(indent + textEscaper.Replace(text) + "\n")
+		}
+	case html.ElementNode:
+		b.WriteString(indent + "<" + n.Data)
+		attrs := make([]string, 0, len(n.Attr))
+		for _, a := range n.Attr {
+			key := a.Key
+			if a.Namespace != "" {
+				key = a.Namespace + ":" + key
+			}
+			if a.Val == "" {
+				attrs = append(attrs, key)
+			} else {
+				attrs = append(attrs, key+`="`+attrEscaper.Replace(a.Val)+`"`)
+			}
+		}
+		sort.Strings(attrs)
+		for _, a := range attrs {
+			b.WriteString(" " + a)
+		}
+		switch n.Data {
+		case "pre", "textarea", "script", "style":
+			// Whitespace is content here: the content is written as serialized, on the tag's
+			// line, with nothing added.
+			b.WriteString(">")
+			if c := n.FirstChild; c != nil && c.Type == html.TextNode && strings.HasPrefix(c.Data, "\n") &&
+				(n.Data == "pre" || n.Data == "textarea") {
+				// The parser drops a newline right after the start tag.
+				b.WriteString("\n")
+			}
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.TextNode && (n.Data == "script" || n.Data == "style") {
+					b.WriteString(c.Data)
+				} else {
+					_ = html.Render(b, c)
+				}
+			}
+			b.WriteString("</" + n.Data + ">\n")
+			return
+		}
+		b.WriteString(">\n")
+		if isVoid(n) {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeNode(b, c, depth+1)
+		}
+		b.WriteString(indent + "</" + n.Data + ">\n")
+	}
+}
+
+// isHTMLSpace reports whether r is HTML whitespace; unlike unicode.IsSpace it excludes the
+// non-breaking space, which is content.
+func isHTMLSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// isVoid reports whether n is an HTML void element, which has no end tag.
+func isVoid(n *html.Node) bool {
+	return n.Namespace == "" && element.IsVoid(n.Data)
+}
+
+// diff returns the first differing line of want and got with a few lines of context.
+func diff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	i := 0
+	for i < len(w) && i < len(g) && w[i] == g[i] {
+		i++
+	}
+	var b strings.Builder
+	for j := max(0, i-3); j < i; j++ {
+		b.WriteString("  " + w[j] + "\n")
+	}
+	for j := i; j < min(len(w), i+3); j++ {
+		b.WriteString("- " + w[j] + "\n")
+	}
+	for j := i; j < min(len(g), i+3); j++ {
+		b.WriteString("+ " + g[j] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package gohtmlxtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdheshnayak/gohtmlx/pkg/element"
+)
+
+func card(title string) element.Element {
+	return element.E(`section`, element.AttrList{{Key: `id`, Value: `card`}, {Key: `class`, Value: `card`}},
+		element.Literal("\n    "),
+		element.E(`h2`, nil, element.R(title)),
+		element.E(`pre`, nil, element.Literal("a  b\n  c")),
+		element.E(`input`, element.AttrList{{Key: `required`, Value: true}, {Key: `name`, Value: `q`}}),
+	)
+}
+
+func TestAssertSnapshot(t *testing.T) {
+	AssertSnapshot(t, card("Fish & Chips"))
+}
+
+func TestNormalize(t *testing.T) {
+	a, err := Normalize(`<p class="x"  id="y">  Hello
+	  world </p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Normalize(`<p id="y" class="x">Hello world</p>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b || a != "<p class=\"x\" id=\"y\">\n  Hello world\n</p>\n" {
+		t.Errorf("attribute order and whitespace should not matter: %q vs %q", a, b)
+	}
+}
+
+func TestNormalize_Pre(t *testing.T) {
+	a, err := Normalize("<div><pre>a <b>x</b>\n</pre></div>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<div>\n  <pre>a <b>x</b>\n</pre>\n</div>\n"; a != want {
+		t.Errorf("got %q, want %q", a, want)
+	}
+	// Whitespace in pre is content, so it must show in the snapshot.
+	b, err := Normalize("<div><pre>a <b>x</b></pre></div>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("pre whitespace should matter: both normalize to %q", a)
+	}
+}
+
+// recorder is a testing.TB that records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	name   string
+	failed string
+}
+
+func (r *recorder) Name() string        { return r.name }
+func (r *recorder) Helper()             {}
+func (r *recorder) Logf(string, ...any) {}
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+
+func TestAssertSnapshot_MismatchAndUpdate(t *testing.T) {
+	old := SnapshotDir
+	SnapshotDir = t.TempDir()
+	defer func() { SnapshotDir = old }()
+
+	r := &recorder{TB: t, name: "TestCard/dark mode"}
+	AssertSnapshot(r, card("A"))
+	if !strings.Contains(r.failed, "does not exist") {
+		t.Fatalf("missing snapshot should fail, got %q", r.failed)
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	r.failed = ""
+	AssertSnapshot(r, card("A"))
+	if r.failed != "" {
+		t.Fatalf("update failed: %s", r.failed)
+	}
+	if _, err := os.Stat(filepath.Join(SnapshotDir, "TestCard__dark_mode.html")); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+
+	t.Setenv(UpdateEnv, "")
+	AssertSnapshot(r, card("A"))
+	if r.failed != "" {
+		t.Errorf("same output should match, got %q", r.failed)
+	}
+	AssertSnapshot(r, card("B"))
+	if !strings.Contains(r.failed, "differs") || !strings.Contains(r.failed, "+     B") {
+		t.Errorf("changed output should fail with a diff, got %q", r.failed)
+	}
+}
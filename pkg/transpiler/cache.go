@@ -0,0 +1,100 @@
+package transpiler
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// cacheSpec is a component's "cache" section:
+//
+//	key: props.Locale, props.Theme   (optional Go expressions the output depends on)
+//	ttl: 5m                          (optional time.ParseDuration value; none: until evicted)
+type cacheSpec struct {
+	Key string `json:"key"`
+	TTL string `json:"ttl"`
+}
+
+// cachedCode wraps the component body code in element.Cached according to the cache section.
+// The body is built inside WithContext so a cache hit skips building it.
+func cachedCode(name, section, code string) (string, error) {
+	args, err := cacheArgs(name, section, code)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Cached(%s, WithContext(func(ctx Context) Element {\nreturn %s\n}))", args, code), nil
+}
+
+// cachedWriterCode is cachedCode for the statements of the writer backend.
+func cachedWriterCode(name, section, code string) (string, error) {
+	args, err := cacheArgs(name, section, code)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("w.Element(Cached(%s, WriteFunc(func(w *Writer) {\n%s})))\n", args, code), nil
+}
+
+// cacheArgs returns the key and ttl arguments of Cached for the cache section of a component
+// whose body is code.
+func cacheArgs(name, section, code string) (string, error) {
+	var spec cacheSpec
+	if err := yaml.Unmarshal([]byte(section), &spec); err != nil {
+		return "", fmt.Errorf("cache: %w", err)
+	}
+	var ttl time.Duration
+	if spec.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(spec.TTL); err != nil {
+			return "", fmt.Errorf("cache: invalid ttl %q", spec.TTL)
+		}
+		if ttl < 0 {
+			return "", fmt.Errorf("cache: ttl %q must not be negative; omit it to keep entries until evicted", spec.TTL)
+		}
+	}
+	if input := callerInput(code); input != "" {
+		return "", fmt.Errorf("cache: the component uses %s, which the cache key cannot include; "+
+			"pass what it needs as props and cache a component that takes no caller content or attributes", input)
+	}
+	key := fmt.Sprintf("%q", name)
+	if k := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(spec.Key), "{"), "}"); k != "" {
+		key += ", " + k
+	}
+	// ttl is emitted in nanoseconds: generated files import only what the templates use.
+	return fmt.Sprintf("CacheKey(%s), %d", key, ttl), nil
+}
+
+// callerInput returns the first input of the component body code that comes from its call
+// site rather than its props: children, slot content, caller attributes or the render context,
+// whose values (e.g. the request's user) the key would have to include. Cached output would
+// replay the first call site's for every other one.
+func callerInput(code string) string {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	s.Init(fset.AddFile("", fset.Base(), len(code)), []byte(code), nil, 0)
+	var afterProps, dot bool // the last token was props; the last two were props and .
+	var afterCtx bool        // the last token was ctx
+	for {
+		_, tok, lit := s.Scan()
+		if afterCtx && !(tok == token.IDENT && lit == "Context") {
+			// ctx followed by Context declares the parameter of an Await's content.
+			return "ctx"
+		}
+		switch {
+		case tok == token.EOF:
+			return ""
+		case tok == token.IDENT && lit == "children":
+			return "children"
+		case tok == token.IDENT && lit == "attrs":
+			return "{...attrs}"
+		case tok == token.IDENT && dot && (lit == "Attrs" || strings.HasPrefix(lit, "Slot")):
+			return "props." + lit
+		}
+		dot = afterProps && tok == token.PERIOD
+		afterProps = tok == token.IDENT && lit == "props"
+		afterCtx = tok == token.IDENT && lit == "ctx"
+	}
+}
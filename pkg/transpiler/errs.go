@@ -1,8 +1,13 @@
 package transpiler
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+
+	"github.com/abdheshnayak/gohtmlx/pkg/element"
+	"github.com/abdheshnayak/gohtmlx/pkg/gocode"
 )
 
 // TranspileError is returned when transpilation fails. Use it so the caller can show
@@ -47,6 +52,31 @@ func lineForComponent(content []byte, name string) int {
 	return 1 + strings.Count(s[:idx], "\n")
 }
 
+// componentSourceAt returns where component name is defined, with the file path relative to
+// src so generated code does not depend on where the transpiler ran.
+func componentSourceAt(src, filePath string, content []byte, name string) gocode.Source {
+	rel, err := filepath.Rel(src, filePath)
+	if err != nil {
+		rel = filePath
+	}
+	return gocode.Source{File: filepath.ToSlash(rel), Line: lineForComponent(content, name)}
+}
+
+// lineForHTMLSection returns the line of the component's `define "html"` block, which is line 1 of
+// the HTML passed to element.NewHtml.
+func lineForHTMLSection(content []byte, name string) int {
+	s := string(content)
+	start := strings.Index(s, `define "`+name+`"`)
+	if start < 0 {
+		return 0
+	}
+	idx := strings.Index(s[start:], `define "html"`)
+	if idx < 0 {
+		return 0
+	}
+	return 1 + strings.Count(s[:start+idx], "\n")
+}
+
 func snippetAtLine(content []byte, line int, contextLines int) string {
 	lines := strings.Split(string(content), "\n")
 	if line < 1 || line > len(lines) {
@@ -68,9 +98,17 @@ func wrapTranspileErr(component, filePath string, fileContent []byte, err error)
 		return nil
 	}
 	line := 0
+	message := err.Error()
 	var snippet string
 	if len(fileContent) > 0 {
 		line = lineForComponent(fileContent, component)
+		var se *element.SourceError
+		if errors.As(err, &se) {
+			message = se.Message
+			if htmlLine := lineForHTMLSection(fileContent, component); htmlLine > 0 {
+				line = htmlLine + se.Line - 1
+			}
+		}
 		if line > 0 {
 			snippet = snippetAtLine(fileContent, line, 2)
 		}
@@ -79,7 +117,7 @@ func wrapTranspileErr(component, filePath string, fileContent []byte, err error)
 		Component: component,
 		FilePath:  filePath,
 		Line:      line,
-		Message:   err.Error(),
+		Message:   message,
 		Snippet:   snippet,
 	}
 }
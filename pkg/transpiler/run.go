@@ -35,8 +35,19 @@ type RunOptions struct {
 	// Incremental skips transpilation when no .html under src is newer than the generated .go files under dist.
 	// Useful in watch scripts to avoid work when nothing changed. Best-effort; a full run is always correct.
 	Incremental bool
+	// Backend selects the generated code: "tree" (default) builds each component's output as
+	// elements; "writer" generates code that writes it directly through an element.Writer, for
+	// the hottest pages. Both render the same output, and writer backend component types also
+	// implement element.Element.
+	Backend string
 }
 
+// Backends accepted by RunOptions.Backend.
+const (
+	BackendTree   = "tree"
+	BackendWriter = "writer"
+)
+
 func defaultOptions(opts *RunOptions) RunOptions {
 	if opts == nil {
 		return RunOptions{Pkg: "gohtmlxc"}
@@ -253,6 +264,10 @@ func newestModTime(dir, ext string) (time.Time, error) {
 
 func Run(src, dist string, opts *RunOptions) error {
 	opt := defaultOptions(opts)
+	writer := opt.Backend == BackendWriter
+	if opt.Backend != "" && opt.Backend != BackendTree && !writer {
+		return &TranspileError{Message: fmt.Sprintf("unknown backend %q (want %q or %q)", opt.Backend, BackendTree, BackendWriter)}
+	}
 	if utils.Log != nil {
 		utils.Log.Info("transpiling...")
 	}
@@ -412,15 +427,29 @@ func Run(src, dist string, opts *RunOptions) error {
 				return wrapTranspileErr(name, filePath, fileContent, err)
 			}
 
-			out, err := h.RenderGolangCode(components)
+			render, cached := h.RenderGolangCode, cachedCode
+			if writer {
+				render, cached = h.RenderWriterCode, cachedWriterCode
+			}
+			out, err := render(components)
 			if err != nil {
 				return wrapTranspileErr(name, filePath, fileContent, err)
 			}
+			if cache, ok := m["cache"]; ok {
+				if out, err = cached(name, cache, out); err != nil {
+					return wrapTranspileErr(name, filePath, fileContent, err)
+				}
+			}
 
 			goCodes[name] = out
 		}
 	}
 
+	sources := make(map[string]gocode.Source, len(goCodes))
+	for name := range goCodes {
+		sources[name] = componentSourceAt(src, componentSource[name], componentFileContent[name], name)
+	}
+
 	outDir := path.Join(dist, opt.Pkg)
 	if err := os.MkdirAll(outDir, 0755); err != nil {
 		return &TranspileError{FilePath: outDir, Message: "failed to create output directory: " + err.Error()}
@@ -432,7 +461,11 @@ func Run(src, dist string, opts *RunOptions) error {
 	}
 
 	if opt.SingleFile {
-		b, err := gocode.ConstructSourceWithPkg(goCodes, structs, imports, opt.Pkg)
+		construct := gocode.ConstructSourceAt
+		if writer {
+			construct = gocode.ConstructWriterSource
+		}
+		b, err := construct(goCodes, sources, structs, imports, opt.Pkg)
 		if err != nil {
 			return &TranspileError{Message: "codegen: " + err.Error()}
 		}
@@ -452,7 +485,11 @@ func Run(src, dist string, opts *RunOptions) error {
 			}
 			structStr := structMap[name]
 			usedImports := importsUsedInComponent(imports, structStr, codeStr)
-			compContent, err := gocode.ConstructComponentFile(opt.Pkg, usedImports, name, structStr, codeStr)
+			construct := gocode.ConstructComponentFileAt
+			if writer {
+				construct = gocode.ConstructWriterComponentFile
+			}
+			compContent, err := construct(opt.Pkg, usedImports, name, sources[name], structStr, codeStr)
 			if err != nil {
 				return &TranspileError{Component: name, FilePath: componentSource[name], Message: "codegen: " + err.Error()}
 			}
@@ -10,7 +10,7 @@ import (
 
 // testdata paths relative to repo root; tests may skip if not found
 const (
-	goldenSrc  = "testdata/golden"
+	goldenSrc   = "testdata/golden"
 	badpropsSrc = "testdata/badprops"
 )
 
@@ -159,3 +159,132 @@ func TestRun_IncrementalSkipsWhenOutputNewer(t *testing.T) {
 		t.Fatalf("incremental Run should skip and return nil: %v", err)
 	}
 }
+
+func TestRun_VoidElementChildrenReportsLine(t *testing.T) {
+	src := t.TempDir()
+	content := "<!-- + define \"Form\" -->\n<!-- | define \"html\" -->\n<form>\n  <input name=\"q\">oops</input>\n</form>\n<!-- | end -->\n<!-- + end -->\n"
+	if err := os.WriteFile(filepath.Join(src, "form.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(src, t.TempDir(), &RunOptions{SingleFile: true})
+	var te *TranspileError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected TranspileError, got %T: %v", err, err)
+	}
+	if te.Line != 4 {
+		t.Errorf("expected line 4, got %d (%s)", te.Line, te.Message)
+	}
+	if !strings.Contains(te.Message, "void element <input>") {
+		t.Errorf("unexpected message: %s", te.Message)
+	}
+}
+
+func TestRun_UnknownElementReportsLine(t *testing.T) {
+	src := t.TempDir()
+	content := "<!-- + define \"Page\" -->\n<!-- | define \"html\" -->\n<main>\n  <my-widget></my-widget>\n  <Sidebarr></Sidebarr>\n</main>\n<!-- | end -->\n<!-- + end -->\n"
+	if err := os.WriteFile(filepath.Join(src, "page.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := Run(src, t.TempDir(), &RunOptions{SingleFile: true})
+	var te *TranspileError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected TranspileError, got %T: %v", err, err)
+	}
+	if te.Line != 5 {
+		t.Errorf("expected line 5, got %d (%s)", te.Line, te.Message)
+	}
+	if !strings.Contains(te.Message, "unknown element <sidebarr>") {
+		t.Errorf("unexpected message: %s", te.Message)
+	}
+}
+
+func TestRun_CacheSection(t *testing.T) {
+	src, dist := t.TempDir(), t.TempDir()
+	content := "<!-- + define \"Footer\" -->\n<!-- | define \"props\" -->\nlocale: string\n<!-- | end -->\n" +
+		"<!-- | define \"cache\" -->\nkey: props.Locale\nttl: 5m\n<!-- | end -->\n" +
+		"<!-- | define \"html\" -->\n<footer>{props.Locale}</footer>\n<!-- | end -->\n<!-- + end -->\n"
+	if err := os.WriteFile(filepath.Join(src, "footer.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(src, dist, &RunOptions{SingleFile: true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	out, err := os.ReadFile(filepath.Join(dist, "gohtmlxc", "comp_generated.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `return Cached(CacheKey("Footer", props.Locale), 300000000000, WithContext(func(ctx Context) Element {`) {
+		t.Errorf("expected cached component body, got:\n%s", out)
+	}
+
+	bad := strings.Replace(content, "ttl: 5m", "ttl: soon", 1)
+	if err := os.WriteFile(filepath.Join(src, "footer.html"), []byte(bad), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(src, dist, &RunOptions{SingleFile: true}); err == nil || !strings.Contains(err.Error(), "invalid ttl") {
+		t.Errorf("expected invalid ttl error, got %v", err)
+	}
+
+	// Input from the call site is not in the key, so caching it would replay one caller's
+	// content for every other.
+	for _, tt := range []struct{ old, new, want string }{
+		{"ttl: 5m", "ttl: -5m", "must not be negative"},
+		{"<footer>{props.Locale}</footer>", "<footer>{children}</footer>", "uses children"},
+		{"<footer>{props.Locale}</footer>", "<footer {...attrs}></footer>", "uses {...attrs}"},
+		{"<footer>{props.Locale}</footer>", `<footer class={props.Attrs["class"]}></footer>`, "uses props.Attrs"},
+		{"<footer>{props.Locale}</footer>", `<footer><slot name="links"/></footer>`, "uses props.SlotLinks"},
+		{"<footer>{props.Locale}</footer>", `<footer>{ctx.Value("user")}</footer>`, "uses ctx"},
+	} {
+		bad := strings.Replace(content, tt.old, tt.new, 1)
+		if err := os.WriteFile(filepath.Join(src, "footer.html"), []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, backend := range []string{BackendTree, BackendWriter} {
+			if err := Run(src, dist, &RunOptions{Backend: backend}); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("%s (%s): expected error containing %q, got %v", tt.new, backend, tt.want, err)
+			}
+		}
+	}
+
+	// The ctx parameter of an <await>'s content is not a use of the render context.
+	ok := strings.Replace(content, "<footer>{props.Locale}</footer>", "<footer><await><p>{props.Locale}</p></await></footer>", 1)
+	if err := os.WriteFile(filepath.Join(src, "footer.html"), []byte(ok), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, backend := range []string{BackendTree, BackendWriter} {
+		if err := Run(src, dist, &RunOptions{Backend: backend}); err != nil {
+			t.Errorf("await (%s): %v", backend, err)
+		}
+	}
+}
+
+func TestRun_WriterBackend(t *testing.T) {
+	src, dist := t.TempDir(), t.TempDir()
+	content := "<!-- + define \"Footer\" -->\n<!-- | define \"props\" -->\nlocale: string\n<!-- | end -->\n" +
+		"<!-- | define \"cache\" -->\nkey: props.Locale\n<!-- | end -->\n" +
+		"<!-- | define \"html\" -->\n<footer>{props.Locale}</footer>\n<!-- | end -->\n<!-- + end -->\n"
+	if err := os.WriteFile(filepath.Join(src, "footer.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(src, dist, &RunOptions{Backend: BackendWriter}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	out, err := os.ReadFile(filepath.Join(dist, "gohtmlxc", "Footer.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`w.Element(Cached(CacheKey("Footer", props.Locale), 0, WriteFunc(func(w *Writer) {`,
+		"w.Open(`footer`, true)",
+		"w.Value(props.Locale)",
+		"func (c Footer) Render(w io.Writer) (int, error) {",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %s in generated code, got:\n%s", want, out)
+		}
+	}
+
+	if err := Run(src, dist, &RunOptions{Backend: "fast"}); err == nil || !strings.Contains(err.Error(), "unknown backend") {
+		t.Errorf("expected unknown backend error, got %v", err)
+	}
+}
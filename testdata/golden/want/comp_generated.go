@@ -20,7 +20,9 @@ func AComp(props A, attrs Attrs, children ...Element) Element {
 		props.Attrs = Attrs{}
 	}
 
-	return R(E(`div`, Attrs{`class`: `x`}, R(props.Name)))
+	return ComponentAt("A", "simple.html", 1, func(ctx Context) Element {
+		return R(E(`div`, AttrList{{Key: `class`, Value: `x`}}, R(props.Name)))
+	})
 
 }
 
@@ -35,7 +37,11 @@ func BComp(props B, attrs Attrs, children ...Element) Element {
 		props.Attrs = Attrs{}
 	}
 
-	return R(E(`span`, Attrs{}, R(`static`)))
+	return ComponentAt("B", "simple.html", 10, func(ctx Context) Element {
+		return R(Static("<span>static</span>", func() Element {
+			return E(`span`, nil, Literal(`static`))
+		}))
+	})
 
 }
 
@@ -14,7 +14,9 @@ func AComp(props A, attrs Attrs, children ...Element) Element {
 	if props.Attrs == nil {
 		props.Attrs = Attrs{}
 	}
-	return R(E(`div`, Attrs{`class`: `x`}, R(props.Name)))
+	return ComponentAt("A", "simple.html", 1, func(ctx Context) Element {
+		return R(E(`div`, AttrList{{Key: `class`, Value: `x`}}, R(props.Name)))
+	})
 }
 
 func (c A) Get(children ...Element) Element {
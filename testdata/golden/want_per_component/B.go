@@ -13,7 +13,11 @@ func BComp(props B, attrs Attrs, children ...Element) Element {
 	if props.Attrs == nil {
 		props.Attrs = Attrs{}
 	}
-	return R(E(`span`, Attrs{}, R(`static`)))
+	return ComponentAt("B", "simple.html", 10, func(ctx Context) Element {
+		return R(Static("<span>static</span>", func() Element {
+			return E(`span`, nil, Literal(`static`))
+		}))
+	})
 }
 
 func (c B) Get(children ...Element) Element {